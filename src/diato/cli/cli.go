@@ -26,6 +26,8 @@ var RootCmd = &cobra.Command{
 
 func init() {
 	RootCmd.AddCommand(
+		configCmd,
+		ctlCmd,
 		daemonCmd,
 		versionCmd,
 		workerCmd,
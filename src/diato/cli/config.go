@@ -0,0 +1,89 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"diato/config"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/gcfg.v1"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspects the configuration file",
+}
+
+var configCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Validates the configuration file and reports all problems found",
+	RunE:  runConfigCheck,
+}
+
+var configDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Shows the effective configuration, including defaults",
+	RunE:  runConfigDump,
+}
+
+func init() {
+	configCmd.AddCommand(
+		configCheckCmd,
+		configDumpCmd,
+	)
+}
+
+func runConfigCheck(cmd *cobra.Command, args []string) error {
+	conf, err := configRead()
+	if err != nil {
+		return err
+	}
+
+	errs := conf.Check()
+	if len(errs) == 0 {
+		fmt.Printf("%s: OK\n", daemonOpts.ConfFile)
+		return nil
+	}
+
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", daemonOpts.ConfFile, err.Error())
+	}
+
+	// Usage is of no help here, the problems were already listed
+	cmd.SilenceUsage = true
+	return fmt.Errorf("Found %d problem(s) in %s", len(errs), daemonOpts.ConfFile)
+}
+
+func runConfigDump(_ *cobra.Command, args []string) error {
+	conf, err := configRead()
+	if err != nil {
+		return err
+	}
+
+	return conf.Dump(os.Stdout)
+}
+
+func configRead() (*config.Config, error) {
+	conf := config.NewConfig()
+	if err := gcfg.ReadFileInto(conf, daemonOpts.ConfFile); err != nil {
+		return nil, fmt.Errorf("Could not parse configuration: %s", err.Error())
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,472 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"diato/config"
+	cachepb "diato/module/cache/pb"
+	pb "diato/pb"
+	"diato/server"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/spf13/cobra"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"gopkg.in/gcfg.v1"
+)
+
+var ctlCmd = &cobra.Command{
+	Use:   "ctl",
+	Short: "Controls a running daemon through its admin API",
+}
+
+var ctlStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Shows the status of the daemon",
+	RunE:  runCtlStatus,
+}
+
+var ctlWorkersCmd = &cobra.Command{
+	Use:   "workers",
+	Short: "Lists the workers",
+	RunE:  runCtlWorkers,
+}
+
+var ctlWorkersRecycleCmd = &cobra.Command{
+	Use:   "recycle [id...]",
+	Short: "Gracefully restarts the given workers, or all of them",
+	RunE:  runCtlWorkersRecycle,
+}
+
+var ctlCertsCmd = &cobra.Command{
+	Use:   "certs",
+	Short: "Lists the loaded TLS certificates",
+	RunE:  runCtlCerts,
+}
+
+var ctlUsermapCmd = &cobra.Command{
+	Use:   "usermap",
+	Short: "Inspects the user backend",
+}
+
+var ctlUsermapLookupCmd = &cobra.Command{
+	Use:   "lookup <host> [path]",
+	Short: "Shows the backend a host, or a path of it, is mapped to",
+	RunE:  runCtlUsermapLookup,
+}
+
+var ctlCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manages the response cache",
+}
+
+var ctlCachePurgeCmd = &cobra.Command{
+	Use:   "purge <host> [url]",
+	Short: "Purges the cached responses of a host, or only those of a url",
+	RunE:  runCtlCachePurge,
+}
+
+var ctlReloadCmd = &cobra.Command{
+	Use:   "reload",
+	Short: "Reloads the user backend",
+	RunE:  runCtlReload,
+}
+
+var ctlStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stops the daemon",
+	RunE:  runCtlStop,
+}
+
+var ctlDrainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "Stops accepting new connections, existing ones are served until they close",
+	RunE:  runCtlDrain,
+}
+
+var ctlOpts = struct {
+	Socket  string
+	Token   string
+	Json    bool
+	Timeout time.Duration
+}{}
+
+func init() {
+	ctlUsermapCmd.AddCommand(
+		ctlUsermapLookupCmd,
+	)
+
+	ctlWorkersCmd.AddCommand(
+		ctlWorkersRecycleCmd,
+	)
+
+	ctlCacheCmd.AddCommand(
+		ctlCachePurgeCmd,
+	)
+
+	ctlCmd.AddCommand(
+		ctlStatusCmd,
+		ctlWorkersCmd,
+		ctlCertsCmd,
+		ctlUsermapCmd,
+		ctlCacheCmd,
+		ctlReloadCmd,
+		ctlStopCmd,
+		ctlDrainCmd,
+	)
+
+	ctlCmd.PersistentFlags().StringVarP(&ctlOpts.Socket,
+		"socket", "", "", "The admin socket to connect to (default: as configured)")
+	ctlCmd.PersistentFlags().StringVarP(&ctlOpts.Token,
+		"token", "", "", "The admin token to present (default: as configured)")
+	ctlCmd.PersistentFlags().BoolVarP(&ctlOpts.Json,
+		"json", "", false, "Print the output as JSON")
+	ctlCmd.PersistentFlags().DurationVarP(&ctlOpts.Timeout,
+		"timeout", "", 10*time.Second, "Timeout for calls to the daemon")
+}
+
+// ctlConnect returns a client for the admin API, as well as a context
+// carrying the admin token that must be used for calls to the API.
+func ctlConnect() (pb.AdminClient, context.Context, func(), error) {
+	conn, ctx, closer, err := ctlDial()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return pb.NewAdminClient(conn), ctx, closer, nil
+}
+
+// ctlDial is like ctlConnect, but returns the connection itself
+// for use with the APIs that modules offer.
+func ctlDial() (*grpc.ClientConn, context.Context, func(), error) {
+	socket, token := ctlOpts.Socket, ctlOpts.Token
+	if socket == "" || token == "" {
+		conf := config.NewConfig()
+		if err := gcfg.ReadFileInto(conf, daemonOpts.ConfFile); err != nil {
+			return nil, nil, nil, fmt.Errorf("Could not parse configuration: %s", err.Error())
+		}
+		if !conf.Admin.Enabled {
+			return nil, nil, nil, errors.New("The admin API is not enabled in " + daemonOpts.ConfFile)
+		}
+		if socket == "" {
+			socket = conf.Admin.SocketPath
+		}
+		if token == "" {
+			token = conf.Admin.Token
+		}
+	}
+
+	conn, err := grpc.Dial(
+		socket,
+		grpc.WithInsecure(),
+		grpc.WithBlock(),
+		grpc.WithTimeout(ctlOpts.Timeout),
+		grpc.WithDialer(func(addr string, timeout time.Duration) (net.Conn, error) {
+			return net.DialTimeout("unix", addr, timeout)
+		}),
+	)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("Could not connect to %s: %s", socket, err.Error())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ctlOpts.Timeout)
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs(server.AdminTokenKey, token))
+
+	closer := func() {
+		cancel()
+		conn.Close()
+	}
+	return conn, ctx, closer, nil
+}
+
+func runCtlStatus(_ *cobra.Command, args []string) error {
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	status, err := client.GetStatus(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	if ctlOpts.Json {
+		return ctlPrintJson(status)
+	}
+
+	w := ctlNewTabWriter()
+	fmt.Fprintf(w, "Pid:\t%d\n", status.Pid)
+	fmt.Fprintf(w, "Uptime:\t%s\n", ctlSince(status.StartedAt))
+	fmt.Fprintf(w, "Draining:\t%t\n", status.Draining)
+	fmt.Fprintf(w, "User map:\t%d entries\n", status.UserBackendSize)
+	fmt.Fprintf(w, "Certificates:\t%d\n", status.CertificateCount)
+	w.Flush()
+
+	fmt.Println()
+	w = ctlNewTabWriter()
+	fmt.Fprintln(w, "LISTENER\tBIND\tTLS\tPROXY PROTOCOL")
+	for _, l := range status.Listeners {
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\n", l.Name, l.Bind, l.Tls, l.ProxyProtocol)
+	}
+	w.Flush()
+
+	fmt.Println()
+	ctlPrintWorkers(status.Workers)
+
+	fmt.Println()
+	w = ctlNewTabWriter()
+	fmt.Fprintln(w, "MODULE\tENABLED")
+	for _, m := range status.Modules {
+		fmt.Fprintf(w, "%s\t%t\n", m.Name, m.Enabled)
+	}
+	return w.Flush()
+}
+
+func runCtlWorkers(_ *cobra.Command, args []string) error {
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	workers, err := client.GetWorkers(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	if ctlOpts.Json {
+		return ctlPrintJson(workers)
+	}
+
+	return ctlPrintWorkers(workers.Workers)
+}
+
+func runCtlWorkersRecycle(_ *cobra.Command, args []string) error {
+	req := &pb.AdminRecycleRequest{Ids: make([]uint32, 0, len(args))}
+	for _, arg := range args {
+		id, err := strconv.ParseUint(arg, 10, 32)
+		if err != nil {
+			return fmt.Errorf("Invalid worker id '%s'", arg)
+		}
+		req.Ids = append(req.Ids, uint32(id))
+	}
+
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if _, err := client.RecycleWorkers(ctx, req); err != nil {
+		return err
+	}
+
+	return ctlPrintResult("Recycling workers")
+}
+
+func runCtlCerts(_ *cobra.Command, args []string) error {
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	certs, err := client.GetCertificates(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	if ctlOpts.Json {
+		return ctlPrintJson(certs)
+	}
+
+	w := ctlNewTabWriter()
+	fmt.Fprintln(w, "PATH\tEXPIRES\tLOADED\tNAMES")
+	for _, cert := range certs.Certificates {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			cert.Path,
+			time.Unix(cert.NotAfter, 0).Format(time.RFC3339),
+			time.Unix(cert.LoadedAt, 0).Format(time.RFC3339),
+			strings.Join(cert.Names, ", "),
+		)
+	}
+	return w.Flush()
+}
+
+func runCtlUsermapLookup(_ *cobra.Command, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errors.New("Expected exactly one host to look up, optionally followed by a path")
+	}
+
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	req := &pb.UserBackendRequest{Name: args[0]}
+	if len(args) == 2 {
+		req.Path = args[1]
+	}
+
+	res, err := client.LookupUser(ctx, req)
+	if err != nil {
+		return err
+	}
+	if ctlOpts.Json {
+		return ctlPrintJson(res)
+	}
+
+	addr := net.JoinHostPort(res.Server, strconv.Itoa(int(res.Port)))
+	if res.Passthrough {
+		addr += " (passthrough)"
+	}
+	if res.StripPrefix != "" {
+		addr += fmt.Sprintf(" (strips %s)", res.StripPrefix)
+	}
+	fmt.Println(addr)
+	return nil
+}
+
+func runCtlCachePurge(_ *cobra.Command, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errors.New("Expected exactly one host to purge, optionally followed by a url")
+	}
+
+	conn, ctx, closer, err := ctlDial()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	req := &cachepb.PurgeRequest{Host: args[0]}
+	if len(args) == 2 {
+		req.Url = args[1]
+	}
+
+	res, err := cachepb.NewModuleCacheAdminClient(conn).PurgeCache(ctx, req)
+	if err != nil {
+		return err
+	}
+	if ctlOpts.Json {
+		return ctlPrintJson(res)
+	}
+
+	fmt.Printf("Purged %s%s, workers apply this within seconds\n", res.Host, res.Url)
+	return nil
+}
+
+func runCtlReload(_ *cobra.Command, args []string) error {
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	res, err := client.ReloadUserBackend(ctx, &empty.Empty{})
+	if err != nil {
+		return err
+	}
+	if ctlOpts.Json {
+		return ctlPrintJson(res)
+	}
+
+	fmt.Printf("Reloaded user backend, it now contains %d entries\n", res.Size)
+	return nil
+}
+
+func runCtlStop(_ *cobra.Command, args []string) error {
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if _, err := client.Stop(ctx, &empty.Empty{}); err != nil {
+		return err
+	}
+
+	return ctlPrintResult("Stopping daemon")
+}
+
+func runCtlDrain(_ *cobra.Command, args []string) error {
+	client, ctx, closer, err := ctlConnect()
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	if _, err := client.Drain(ctx, &empty.Empty{}); err != nil {
+		return err
+	}
+
+	return ctlPrintResult("Draining daemon")
+}
+
+func ctlPrintWorkers(workers []*pb.AdminWorker) error {
+	w := ctlNewTabWriter()
+	fmt.Fprintln(w, "WORKER\tPID\tUPTIME\tRESTARTS\tCONNS\tHEALTHY\tMODULES")
+	for _, worker := range workers {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%d\t%d\t%t\t%s\n",
+			worker.Id,
+			worker.Pid,
+			ctlSince(worker.StartedAt),
+			worker.Restarts,
+			worker.Connections,
+			worker.Healthy,
+			strings.Join(worker.Modules, ", "),
+		)
+	}
+	return w.Flush()
+}
+
+// ctlPrintResult is used by commands that have nothing
+// to report other than that they succeeded.
+func ctlPrintResult(msg string) error {
+	if ctlOpts.Json {
+		return ctlPrintJson(map[string]bool{"ok": true})
+	}
+
+	fmt.Println(msg)
+	return nil
+}
+
+func ctlPrintJson(v interface{}) error {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+	return nil
+}
+
+func ctlNewTabWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+}
+
+func ctlSince(unix int64) time.Duration {
+	return time.Since(time.Unix(unix, 0)) / time.Second * time.Second
+}
@@ -16,6 +16,14 @@
 package cli
 
 import (
+	_ "diato/module/accesslog"
+	_ "diato/module/acl"
+	_ "diato/module/cache"
 	_ "diato/module/elasticsearch"
+	_ "diato/module/geoblock"
+	_ "diato/module/headers"
+	_ "diato/module/https"
 	_ "diato/module/modsec"
+	_ "diato/module/ratelimit"
+	_ "diato/module/rewrite"
 )
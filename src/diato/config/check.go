@@ -0,0 +1,251 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// Check performs a deep validation of the configuration, looking at
+// the file system and the sections of the modules as well. Contrary
+// to Validate(), all problems found are returned rather than just the
+// first one. Nothing is started or listened on.
+func (c *Config) Check() []error {
+	errs := c.validate()
+	errs = append(errs, c.checkGeneral()...)
+	errs = append(errs, c.checkListen()...)
+	errs = append(errs, c.checkAdmin()...)
+	errs = append(errs, c.checkMetrics()...)
+
+	if !c.FilemapUserbackend.Enabled {
+		errs = append(errs, errors.New("No user backends were enabled"))
+	}
+	errs = append(errs, prefixErrors("[filemap-userbackend]", c.FilemapUserbackend.Check())...)
+	errs = append(errs, prefixErrors("[geoip]", c.GeoIp.Check())...)
+	errs = append(errs, prefixErrors("[access-log]", c.AccessLog.Check())...)
+	errs = append(errs, prefixErrors("[acl]", c.Acl.Check())...)
+	errs = append(errs, prefixErrors("[cache]", c.Cache.Check())...)
+	errs = append(errs, prefixErrors("[elasticsearch]", c.Elasticsearch.Check())...)
+	errs = append(errs, prefixErrors("[geoblock]", c.Geoblock.Check(c.GeoIp.Enabled))...)
+	errs = append(errs, prefixErrors("[headers]", c.Headers.Check(c.listenNames()))...)
+	errs = append(errs, prefixErrors("[https]", c.Https.Check())...)
+	errs = append(errs, prefixErrors("[modsecurity]", c.Modsec.Check())...)
+	errs = append(errs, prefixErrors("[rewrite]", c.Rewrite.Check())...)
+	errs = append(errs, c.checkRatelimit()...)
+
+	return errs
+}
+
+func (c *Config) checkGeneral() []error {
+	errs := make([]error, 0)
+
+	if err := checkSocketPath(c.General.HttpSocketPath); err != nil {
+		errs = append(errs, fmt.Errorf("http-socket-path: %s", err.Error()))
+	}
+	if err := checkSocketPath(c.General.HttpsSocketPath); err != nil {
+		errs = append(errs, fmt.Errorf("https-socket-path: %s", err.Error()))
+	}
+	if c.General.HttpSocketPath != "" && c.General.HttpSocketPath == c.General.HttpsSocketPath {
+		errs = append(errs, errors.New("http-socket-path and https-socket-path must differ"))
+	}
+
+	if err := checkDir(c.General.Chroot); err != nil {
+		errs = append(errs, fmt.Errorf("chroot: %s", err.Error()))
+	}
+
+	if c.General.WorkerCount == 0 {
+		errs = append(errs, errors.New("worker-count must be at least 1"))
+	}
+
+	tlsEnabled := false
+	for _, l := range c.Listen {
+		tlsEnabled = tlsEnabled || l.TlsEnable
+	}
+	for _, s := range c.Stream {
+		tlsEnabled = tlsEnabled || s.Mode == StreamModeTls
+	}
+	if tlsEnabled {
+		if err := checkDir(c.General.TlsCertDir); err != nil {
+			errs = append(errs, fmt.Errorf("tls-cert-dir: %s", err.Error()))
+		}
+	}
+
+	return prefixErrors("[diato]", errs)
+}
+
+func (c *Config) checkListen() []error {
+	errs := make([]error, 0)
+
+	type bind struct {
+		section string
+		address string
+		host    string
+	}
+
+	// Sorted, so the output is the same on every run
+	binds := make([]bind, 0, len(c.Listen)+len(c.Stream))
+	for name, l := range c.Listen {
+		binds = append(binds, bind{section: fmt.Sprintf("[listen \"%s\"]", name), address: l.Bind})
+	}
+	for name, s := range c.Stream {
+		binds = append(binds, bind{section: fmt.Sprintf("[stream \"%s\"]", name), address: s.Bind})
+	}
+	sort.Slice(binds, func(i, j int) bool {
+		return binds[i].section < binds[j].section
+	})
+
+	ports := make(map[int][]bind)
+	for _, b := range binds {
+		host, portStr, err := net.SplitHostPort(b.address)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s Invalid bind address '%s': %s",
+				b.section, b.address, err.Error()))
+			continue
+		}
+
+		port, err := net.LookupPort("tcp", portStr)
+		if err != nil || port == 0 {
+			errs = append(errs, fmt.Errorf("%s Invalid port '%s'", b.section, portStr))
+			continue
+		}
+
+		if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+			host = ""
+		}
+		b.host = host
+
+		for _, other := range ports[port] {
+			if host == "" || other.host == "" || host == other.host {
+				errs = append(errs, fmt.Errorf("%s Bind address '%s' conflicts with %s",
+					b.section, b.address, other.section))
+			}
+		}
+		ports[port] = append(ports[port], b)
+	}
+
+	return errs
+}
+
+func (c *Config) checkAdmin() []error {
+	errs := make([]error, 0)
+	if !c.Admin.Enabled {
+		return errs
+	}
+
+	if err := checkSocketPath(c.Admin.SocketPath); err != nil {
+		errs = append(errs, fmt.Errorf("socket-path: %s", err.Error()))
+	}
+	if c.Admin.SocketPath == c.General.HttpSocketPath || c.Admin.SocketPath == c.General.HttpsSocketPath {
+		errs = append(errs, errors.New("socket-path must differ from the http(s) socket paths"))
+	}
+
+	return prefixErrors("[admin]", errs)
+}
+
+func (c *Config) checkMetrics() []error {
+	errs := make([]error, 0)
+	if !c.Metrics.Enabled {
+		return errs
+	}
+
+	if _, _, err := net.SplitHostPort(c.Metrics.Bind); err != nil {
+		errs = append(errs, fmt.Errorf("Invalid bind address '%s': %s", c.Metrics.Bind, err.Error()))
+	}
+	if !strings.HasPrefix(c.Metrics.Path, "/") {
+		errs = append(errs, fmt.Errorf("Path '%s' must start with a '/'", c.Metrics.Path))
+	}
+
+	return prefixErrors("[metrics]", errs)
+}
+
+func (c *Config) listenNames() []string {
+	names := make([]string, 0, len(c.Listen))
+	for name := range c.Listen {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func (c *Config) checkRatelimit() []error {
+	errs := make([]error, 0)
+
+	// Sorted, so the output is the same on every run
+	names := make([]string, 0, len(c.Ratelimit))
+	for name := range c.Ratelimit {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		section := fmt.Sprintf("[ratelimit \"%s\"]", name)
+		errs = append(errs, prefixErrors(section, c.Ratelimit[name].Check())...)
+	}
+
+	return errs
+}
+
+// checkSocketPath verifies a unix socket can be created at the given
+// path. Whether the socket already exists is not considered, that
+// would be the case for any daemon that's already running.
+func checkSocketPath(path string) error {
+	if path == "" {
+		return errors.New("No path was set")
+	}
+
+	dir := filepath.Dir(path)
+	if err := checkDir(dir); err != nil {
+		return err
+	}
+
+	if err := syscall.Access(dir, 0x2|0x1); err != nil { // W_OK|X_OK
+		return fmt.Errorf("Directory '%s' is not writable: %s", dir, err.Error())
+	}
+
+	return nil
+}
+
+func checkDir(path string) error {
+	if path == "" {
+		return errors.New("No path was set")
+	}
+
+	fileinfo, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("Could not open '%s': %s", path, err.Error())
+	}
+
+	if !fileinfo.IsDir() {
+		return fmt.Errorf("'%s' must be a directory, but it does not appear to be", path)
+	}
+
+	return nil
+}
+
+func prefixErrors(prefix string, errs []error) []error {
+	for i, err := range errs {
+		errs[i] = fmt.Errorf("%s %s", prefix, err.Error())
+	}
+
+	return errs
+}
@@ -17,24 +17,46 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"time"
 
+	"diato/geoip"
 	"diato/userbackend/filemap"
 
+	accesslog "diato/module/accesslog/config"
+	acl "diato/module/acl/config"
+	cache "diato/module/cache/config"
 	elasticsearch "diato/module/elasticsearch/worker/config"
+	geoblock "diato/module/geoblock/config"
+	headers "diato/module/headers/config"
+	https "diato/module/https/config"
 	modsec "diato/module/modsec/server/config"
+	ratelimit "diato/module/ratelimit/config"
+	rewrite "diato/module/rewrite/config"
 )
 
 type Config struct {
 	General            GeneralConfig  `gcfg:"diato"`
 	FilemapUserbackend Filemap.Config `gcfg:"filemap-userbackend"`
-	Listen             map[string]*struct {
-		Bind          string
-		TlsEnable     bool `gcfg:"tls-enable"`
-		ProxyProtocol bool `gcfg:"proxy-protocol"`
-	}
+	Listen             map[string]*ListenConfig
+	Stream             map[string]*StreamConfig
+
+	GeoIp   geoip.Config  `gcfg:"geoip"`
+	Admin   AdminConfig   `gcfg:"admin"`
+	Metrics MetricsConfig `gcfg:"metrics"`
 
+	AccessLog     accesslog.Config     `gcfg:"access-log"`
+	Acl           acl.Config           `gcfg:"acl"`
+	Cache         cache.Config         `gcfg:"cache"`
 	Elasticsearch elasticsearch.Config `gcfg:"elasticsearch"`
+	Geoblock      geoblock.Config      `gcfg:"geoblock"`
+	Headers       headers.Config       `gcfg:"headers"`
+	Https         https.Config         `gcfg:"https"`
 	Modsec        modsec.Config        `gcfg:"modsecurity"`
+	Rewrite       rewrite.Config       `gcfg:"rewrite"`
+
+	Ratelimit map[string]*ratelimit.Limit `gcfg:"ratelimit"`
 }
 
 type GeneralConfig struct {
@@ -43,6 +65,52 @@ type GeneralConfig struct {
 	Chroot          string
 	TlsCertDir      string `gcfg:"tls-cert-dir"`
 	WorkerCount     uint   `gcfg:"worker-count"`
+	WorkerDispatch  string `gcfg:"worker-dispatch"`
+}
+
+type ListenConfig struct {
+	Bind          string
+	TlsEnable     bool `gcfg:"tls-enable"`
+	ProxyProtocol bool `gcfg:"proxy-protocol"`
+
+	// Passes TLS on as is for the users marked passthrough,
+	// optionally sending a PROXY header to their server.
+	TlsPassthrough           bool `gcfg:"tls-passthrough"`
+	PassthroughProxyProtocol bool `gcfg:"passthrough-proxy-protocol"`
+
+	// Zero means the default applies, -1 means unlimited
+	MaxConnections      int `gcfg:"max-connections"`
+	MaxConnectionsPerIp int `gcfg:"max-connections-per-ip"`
+
+	// In bytes, zero means the default applies
+	MaxHeaderSize int `gcfg:"max-header-size"`
+
+	// Durations, e.g. '10s'. Empty means the default
+	// applies, '0' means there's no timeout at all.
+	HeaderReadTimeout string `gcfg:"header-read-timeout"`
+	IdleTimeout       string `gcfg:"idle-timeout"`
+}
+
+// ListenLimits holds the limits of a listen section with the
+// defaults applied. Zero means unlimited, or no timeout.
+type ListenLimits struct {
+	MaxConnections      int
+	MaxConnectionsPerIp int
+	MaxHeaderSize       int
+	HeaderReadTimeout   time.Duration
+	IdleTimeout         time.Duration
+}
+
+type AdminConfig struct {
+	Enabled    bool
+	SocketPath string `gcfg:"socket-path"`
+	Token      string `dump:"redact"`
+}
+
+type MetricsConfig struct {
+	Enabled bool
+	Bind    string
+	Path    string
 }
 
 func NewConfig() *Config {
@@ -50,14 +118,131 @@ func NewConfig() *Config {
 		General: GeneralConfig{
 			HttpSocketPath: "/var/run/diato/http.socket",
 			Chroot:         "/var/run/diato/chroot",
+			WorkerDispatch: "least-connections",
+		},
+		Admin: AdminConfig{
+			SocketPath: "/var/run/diato/admin.socket",
+		},
+		Metrics: MetricsConfig{
+			Bind: "127.0.0.1:9145",
+			Path: "/metrics",
+		},
+		AccessLog: accesslog.Config{
+			Format:    "combined",
+			Output:    "stdout",
+			SyslogTag: "diato",
+		},
+		Geoblock: geoblock.Config{
+			DenyStatus: 403,
 		},
 	}
 }
 
 func (c *Config) Validate() error {
-	if len(c.Listen) == 0 {
-		return errors.New("No listen sections defined, expected at least one")
+	if errs := c.validate(); len(errs) > 0 {
+		return errs[0]
 	}
 
 	return nil
 }
+
+// validate returns everything that renders the configuration unusable.
+// Check() reports all of them, whereas Validate() stops at the first.
+func (c *Config) validate() []error {
+	errs := make([]error, 0)
+
+	if len(c.Listen) == 0 {
+		errs = append(errs, errors.New("No listen sections defined, expected at least one"))
+	}
+
+	switch c.General.WorkerDispatch {
+	case "least-connections", "client-ip-hash":
+	default:
+		errs = append(errs, fmt.Errorf("worker-dispatch must be one of least-connections or client-ip-hash, got '%s'",
+			c.General.WorkerDispatch))
+	}
+
+	// Sorted, so the output is the same on every run
+	listens := make([]string, 0, len(c.Listen))
+	for name := range c.Listen {
+		listens = append(listens, name)
+	}
+	sort.Strings(listens)
+	for _, name := range listens {
+		l := c.Listen[name]
+		if _, err := l.Limits(); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid listen section '%s': %s", name, err.Error()))
+		}
+		if l.TlsPassthrough && !l.TlsEnable {
+			errs = append(errs, fmt.Errorf("Invalid listen section '%s': tls-passthrough requires tls-enable", name))
+		}
+	}
+
+	streams := make([]string, 0, len(c.Stream))
+	for name := range c.Stream {
+		streams = append(streams, name)
+	}
+	sort.Strings(streams)
+	for _, name := range streams {
+		if _, err := c.Stream[name].Options(); err != nil {
+			errs = append(errs, fmt.Errorf("Invalid stream section '%s': %s", name, err.Error()))
+		}
+	}
+
+	if c.Admin.Enabled && c.Admin.Token == "" {
+		errs = append(errs, errors.New("The admin API was enabled, but no token was set"))
+	}
+
+	return errs
+}
+
+// Limits returns the limits of the listen section, with the defaults
+// applied. These are generous enough for any legitimate client, while
+// preventing a single one from exhausting our file descriptors.
+func (l *ListenConfig) Limits() (*ListenLimits, error) {
+	limits := &ListenLimits{
+		MaxConnections:      limitOrDefault(l.MaxConnections, 20000),
+		MaxConnectionsPerIp: limitOrDefault(l.MaxConnectionsPerIp, 256),
+		MaxHeaderSize:       l.MaxHeaderSize,
+		HeaderReadTimeout:   10 * time.Second,
+		IdleTimeout:         120 * time.Second,
+	}
+
+	if l.MaxConnections < -1 || l.MaxConnectionsPerIp < -1 {
+		return nil, errors.New("max-connections and max-connections-per-ip must be -1 or more")
+	}
+	if l.MaxHeaderSize < 0 {
+		return nil, errors.New("max-header-size must not be negative")
+	}
+	if limits.MaxHeaderSize == 0 {
+		limits.MaxHeaderSize = 1 << 20 // Same as net/http
+	}
+
+	var err error
+	if l.HeaderReadTimeout != "" {
+		if limits.HeaderReadTimeout, err = time.ParseDuration(l.HeaderReadTimeout); err != nil {
+			return nil, fmt.Errorf("Invalid header-read-timeout: %s", err.Error())
+		}
+	}
+	if l.IdleTimeout != "" {
+		if limits.IdleTimeout, err = time.ParseDuration(l.IdleTimeout); err != nil {
+			return nil, fmt.Errorf("Invalid idle-timeout: %s", err.Error())
+		}
+	}
+	if limits.HeaderReadTimeout < 0 || limits.IdleTimeout < 0 {
+		return nil, errors.New("header-read-timeout and idle-timeout must not be negative")
+	}
+
+	return limits, nil
+}
+
+func limitOrDefault(limit, dflt int) int {
+	switch limit {
+	case 0:
+		return dflt
+	case -1:
+		return 0
+	}
+
+	return limit
+}
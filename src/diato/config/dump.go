@@ -0,0 +1,158 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Values of fields tagged with `dump:"redact"` are not shown by Dump()
+const redacted = "<redacted>"
+
+// Dump writes the effective configuration, including all defaults,
+// in the same format as it is read. Sections are written in the order
+// in which they are defined in the Config struct.
+func (c *Config) Dump(w io.Writer) error {
+	out := bufio.NewWriter(w)
+
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		name := fieldName(v.Type().Field(i))
+		field := v.Field(i)
+
+		switch field.Kind() {
+		case reflect.Struct:
+			dumpSection(out, "["+name+"]", field)
+		case reflect.Map:
+			keys := make([]string, 0, field.Len())
+			for _, key := range field.MapKeys() {
+				keys = append(keys, key.String())
+			}
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				subsection := field.MapIndex(reflect.ValueOf(key)).Interface()
+				if d, ok := subsection.(defaulter); ok {
+					subsection = d.withDefaults()
+				}
+				dumpSection(out, fmt.Sprintf("[%s %s]", name, strconv.Quote(key)), reflect.Indirect(reflect.ValueOf(subsection)))
+			}
+		}
+	}
+
+	return out.Flush()
+}
+
+// defaulter is implemented by sections whose defaults are resolved
+// when they're used, rather than being set by NewConfig().
+type defaulter interface {
+	// A copy of the section with the defaults that apply filled in
+	withDefaults() interface{}
+}
+
+// withDefaults fills in the limits that apply. Invalid sections are
+// left as is, Check() reports those.
+func (l *ListenConfig) withDefaults() interface{} {
+	limits, err := l.Limits()
+	if err != nil {
+		return l
+	}
+
+	dflt := *l
+	dflt.MaxConnections = dumpLimit(limits.MaxConnections)
+	dflt.MaxConnectionsPerIp = dumpLimit(limits.MaxConnectionsPerIp)
+	dflt.MaxHeaderSize = limits.MaxHeaderSize
+	dflt.HeaderReadTimeout = limits.HeaderReadTimeout.String()
+	dflt.IdleTimeout = limits.IdleTimeout.String()
+	return &dflt
+}
+
+// withDefaults fills in the options that apply. Invalid sections are
+// left as is, Check() reports those.
+func (s *StreamConfig) withDefaults() interface{} {
+	opts, err := s.Options()
+	if err != nil {
+		return s
+	}
+
+	dflt := *s
+	dflt.MaxConnections = dumpLimit(opts.MaxConnections)
+	dflt.ConnectTimeout = opts.ConnectTimeout.String()
+	dflt.IdleTimeout = opts.IdleTimeout.String()
+	return &dflt
+}
+
+// dumpLimit turns a resolved limit back into its configured form,
+// in which unlimited is -1 rather than zero.
+func dumpLimit(limit int) int {
+	if limit == 0 {
+		return -1
+	}
+
+	return limit
+}
+
+func dumpSection(out *bufio.Writer, header string, v reflect.Value) {
+	fmt.Fprintln(out, header)
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name := fieldName(field)
+
+		if field.Tag.Get("dump") == "redact" {
+			if v.Field(i).String() != "" {
+				fmt.Fprintf(out, "%s = %s\n", name, redacted)
+			}
+			continue
+		}
+
+		if v.Field(i).Kind() == reflect.Slice {
+			for j := 0; j < v.Field(i).Len(); j++ {
+				fmt.Fprintf(out, "%s = %s\n", name, dumpValue(v.Field(i).Index(j)))
+			}
+			continue
+		}
+
+		fmt.Fprintf(out, "%s = %s\n", name, dumpValue(v.Field(i)))
+	}
+
+	fmt.Fprintln(out)
+}
+
+func dumpValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// fieldName returns the name gcfg uses for the given field
+func fieldName(field reflect.StructField) string {
+	tag := strings.Split(field.Tag.Get("gcfg"), ",")[0]
+	if tag != "" {
+		return tag
+	}
+
+	return strings.ToLower(field.Name)
+}
@@ -0,0 +1,117 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// The modes a stream section can operate in
+const (
+	// Plain TCP, proxied as is
+	StreamModeTcp = "tcp"
+
+	// TLS is terminated by us, the upstream gets the plain stream
+	StreamModeTls = "tls"
+
+	// TLS is passed on to the upstream as is. The upstream is picked
+	// by the server name the client sent along with its ClientHello.
+	StreamModePassthrough = "passthrough"
+)
+
+// StreamConfig describes a [stream "name"] section. Streams are proxied
+// to their upstream byte for byte, without the workers being involved.
+type StreamConfig struct {
+	Bind          string
+	Mode          string
+	ProxyProtocol bool `gcfg:"proxy-protocol"`
+
+	// Sends a PROXY header to the upstream, so it learns the client's address
+	SendProxyProtocol bool `gcfg:"send-proxy-protocol"`
+
+	// A fixed upstream (host:port). If not set, the upstream is looked
+	// up in the user backend by the server name the client asked for.
+	Backend string
+
+	// Zero means the default applies, -1 means unlimited
+	MaxConnections int `gcfg:"max-connections"`
+
+	// Durations, e.g. '10s'. Empty means the default applies.
+	ConnectTimeout string `gcfg:"connect-timeout"`
+	IdleTimeout    string `gcfg:"idle-timeout"` // '0' means there's no timeout at all
+}
+
+// StreamOptions holds the settings of a stream section with the
+// defaults applied.
+type StreamOptions struct {
+	MaxConnections int // Zero means unlimited
+	ConnectTimeout time.Duration
+	IdleTimeout    time.Duration // Zero means no timeout
+}
+
+// Options returns the settings of the stream section, with the defaults
+// applied. The idle timeout is a lot more generous than for http, as
+// protocols like IMAP keep connections open for a long time.
+func (s *StreamConfig) Options() (*StreamOptions, error) {
+	opts := &StreamOptions{
+		MaxConnections: limitOrDefault(s.MaxConnections, 20000),
+		ConnectTimeout: 5 * time.Second,
+		IdleTimeout:    30 * time.Minute,
+	}
+
+	switch s.Mode {
+	case StreamModeTcp:
+		if s.Backend == "" {
+			return nil, errors.New("A backend is required in tcp mode, as there's no server name to go by")
+		}
+	case StreamModeTls, StreamModePassthrough:
+	default:
+		return nil, fmt.Errorf("Invalid mode '%s', expected one of tcp, tls or passthrough", s.Mode)
+	}
+
+	if s.Backend != "" {
+		if _, _, err := net.SplitHostPort(s.Backend); err != nil {
+			return nil, fmt.Errorf("Invalid backend '%s': %s", s.Backend, err.Error())
+		}
+	}
+
+	if s.MaxConnections < -1 {
+		return nil, errors.New("max-connections must be -1 or more")
+	}
+
+	var err error
+	if s.ConnectTimeout != "" {
+		if opts.ConnectTimeout, err = time.ParseDuration(s.ConnectTimeout); err != nil {
+			return nil, fmt.Errorf("Invalid connect-timeout: %s", err.Error())
+		}
+		if opts.ConnectTimeout <= 0 {
+			return nil, errors.New("connect-timeout must be positive")
+		}
+	}
+	if s.IdleTimeout != "" {
+		if opts.IdleTimeout, err = time.ParseDuration(s.IdleTimeout); err != nil {
+			return nil, fmt.Errorf("Invalid idle-timeout: %s", err.Error())
+		}
+		if opts.IdleTimeout < 0 {
+			return nil, errors.New("idle-timeout must not be negative")
+		}
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,66 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package geoip
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type Config struct {
+	Enabled         bool
+	CountryDatabase string `gcfg:"country-database"`
+	CityDatabase    string `gcfg:"city-database"`
+	AsnDatabase     string `gcfg:"asn-database"`
+}
+
+// Check validates the configuration by opening each of the databases.
+// All problems found are returned, rather than just the first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if c.CountryDatabase == "" && c.CityDatabase == "" && c.AsnDatabase == "" {
+		return append(errs, errors.New("No databases were configured"))
+	}
+
+	for _, path := range c.paths() {
+		reader, err := maxminddb.Open(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not open database '%s': %s", path, err.Error()))
+			continue
+		}
+		reader.Close()
+	}
+
+	return errs
+}
+
+// paths returns the paths of all databases that were configured
+func (c *Config) paths() []string {
+	paths := make([]string, 0)
+	for _, path := range []string{c.CountryDatabase, c.CityDatabase, c.AsnDatabase} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
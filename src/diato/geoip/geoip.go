@@ -0,0 +1,198 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package geoip looks up the location and network of IP addresses in
+// MaxMind (mmdb) databases. The databases are reloaded as they change
+// on disk. Only the server has access to them, workers query the
+// server through RPC.
+package geoip
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"path/filepath"
+	"sync"
+
+	pb "diato/pb"
+	"diato/util/watch"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+type Database struct {
+	sync.RWMutex
+
+	// By absolute path
+	readers map[string]*maxminddb.Reader
+
+	countryPath string
+	cityPath    string
+	asnPath     string
+}
+
+// The subset of the records in the MaxMind databases we're interested in
+type countryRecord struct {
+	Continent struct {
+		Code  string            `maxminddb:"code"`
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"continent"`
+	Country struct {
+		IsoCode string            `maxminddb:"iso_code"`
+		Names   map[string]string `maxminddb:"names"`
+	} `maxminddb:"country"`
+}
+
+type cityRecord struct {
+	countryRecord
+
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"subdivisions"`
+	Location struct {
+		Latitude  float64 `maxminddb:"latitude"`
+		Longitude float64 `maxminddb:"longitude"`
+	} `maxminddb:"location"`
+}
+
+type asnRecord struct {
+	Number       uint32 `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+func New(config *Config) (*Database, error) {
+	d := &Database{
+		readers: make(map[string]*maxminddb.Reader),
+	}
+
+	var err error
+	if d.countryPath, err = absPath(config.CountryDatabase); err != nil {
+		return nil, err
+	}
+	if d.cityPath, err = absPath(config.CityDatabase); err != nil {
+		return nil, err
+	}
+	if d.asnPath, err = absPath(config.AsnDatabase); err != nil {
+		return nil, err
+	}
+
+	for _, path := range []string{d.countryPath, d.cityPath, d.asnPath} {
+		if path == "" {
+			continue
+		}
+		if err := d.load(path); err != nil {
+			return nil, err
+		}
+
+		path := path
+		err := watch.File(path, func() {
+			if err := d.load(path); err != nil {
+				log.Printf("Could not reload GeoIP database, keeping the previous version: %s", err.Error())
+			}
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Could not watch GeoIP database: %s", err.Error())
+		}
+	}
+
+	return d, nil
+}
+
+func absPath(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("Could not determine absolute path to '%s': %s", path, err.Error())
+	}
+
+	return abs, nil
+}
+
+// load (re)loads the database at the given path. The
+// previous version is only closed once it's no longer used.
+func (d *Database) load(path string) error {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return fmt.Errorf("Could not open GeoIP database '%s': %s", path, err.Error())
+	}
+
+	d.Lock()
+	prev := d.readers[path]
+	d.readers[path] = reader
+	d.Unlock()
+
+	if prev != nil {
+		prev.Close()
+	}
+
+	log.Printf("Loaded GeoIP database '%s' (%s, built %d)",
+		path, reader.Metadata.DatabaseType, reader.Metadata.BuildEpoch)
+	return nil
+}
+
+// Lookup returns whatever the databases know about the given IP
+func (d *Database) Lookup(ip net.IP) (*pb.GeoIpResponse, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	res := &pb.GeoIpResponse{}
+	if reader := d.readers[d.cityPath]; reader != nil {
+		record := &cityRecord{}
+		if err := reader.Lookup(ip, record); err != nil {
+			return nil, err
+		}
+
+		populateCountry(res, &record.countryRecord)
+		res.CityName = record.City.Names["en"]
+		if len(record.Subdivisions) > 0 {
+			res.RegionName = record.Subdivisions[0].Names["en"]
+		}
+		res.Latitude = record.Location.Latitude
+		res.Longitude = record.Location.Longitude
+	} else if reader := d.readers[d.countryPath]; reader != nil {
+		record := &countryRecord{}
+		if err := reader.Lookup(ip, record); err != nil {
+			return nil, err
+		}
+
+		populateCountry(res, record)
+	}
+
+	if reader := d.readers[d.asnPath]; reader != nil {
+		record := &asnRecord{}
+		if err := reader.Lookup(ip, record); err != nil {
+			return nil, err
+		}
+
+		res.Asn = record.Number
+		res.AsOrganization = record.Organization
+	}
+
+	return res, nil
+}
+
+func populateCountry(res *pb.GeoIpResponse, record *countryRecord) {
+	res.ContinentCode = record.Continent.Code
+	res.ContinentName = record.Continent.Names["en"]
+	res.CountryIsoCode = record.Country.IsoCode
+	res.CountryName = record.Country.Names["en"]
+}
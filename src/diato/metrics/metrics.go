@@ -0,0 +1,275 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics keeps track of counters and histograms in both the
+// server and the workers. Workers periodically Drain() theirs and
+// report them to the server, which Merge()s them into its own so
+// they can be exposed from a single place.
+package metrics
+
+import (
+	"sort"
+	"sync"
+)
+
+var (
+	HttpRequests = NewCounter(
+		"diato_http_requests_total",
+		"Number of HTTP requests handled",
+		"host", "method", "status",
+	)
+
+	HttpRequestBytes = NewCounter(
+		"diato_http_request_bytes_total",
+		"Number of bytes received in HTTP request bodies",
+		"host",
+	)
+
+	HttpResponseBytes = NewCounter(
+		"diato_http_response_bytes_total",
+		"Number of bytes sent in HTTP response bodies",
+		"host",
+	)
+
+	UpstreamDuration = NewHistogram(
+		"diato_upstream_duration_seconds",
+		"Time it took for the upstream to respond with headers",
+		[]float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		"host",
+	)
+
+	UpstreamErrors = NewCounter(
+		"diato_upstream_errors_total",
+		"Number of requests for which the upstream could not be reached",
+		"host",
+	)
+
+	TlsHandshakes = NewCounter(
+		"diato_tls_handshakes_total",
+		"Number of TLS handshakes, by whether they succeeded or were passed through",
+		"result",
+	)
+
+	ConnectionsDropped = NewCounter(
+		"diato_connections_dropped_total",
+		"Number of client connections closed because they exceeded a limit",
+		"listen", "reason",
+	)
+
+	ConnectionErrors = NewCounter(
+		"diato_connection_errors_total",
+		"Number of errors while accepting or forwarding client connections",
+		"listen", "reason",
+	)
+
+	ModsecInterventions = NewCounter(
+		"diato_modsec_interventions_total",
+		"Number of requests ModSecurity would have intervened in",
+	)
+
+	GeoblockUnknownClients = NewCounter(
+		"diato_geoblock_unknown_clients_total",
+		"Number of requests geoblock rules on country or ASN could not be applied to, as the client could not be located",
+		"allowed",
+	)
+
+	RateLimited = NewCounter(
+		"diato_rate_limited_total",
+		"Number of requests denied because a rate limit was exceeded",
+		"limit",
+	)
+
+	WorkerRestarts = NewCounter(
+		"diato_worker_restarts_total",
+		"Number of times a worker was restarted",
+		"worker",
+	)
+
+	WorkerHealthCheckFailures = NewCounter(
+		"diato_worker_health_check_failures_total",
+		"Number of health checks a worker failed",
+		"worker",
+	)
+)
+
+// Limits the number of label combinations kept per metric, so a
+// client can't exhaust our memory by sending random Host headers.
+const maxSeriesPerMetric = 10000
+
+var registered = struct {
+	sync.RWMutex
+	byName map[string]*Metric
+}{
+	byName: make(map[string]*Metric),
+}
+
+// Metric holds all series of either a counter or a histogram
+type Metric struct {
+	sync.Mutex
+
+	Name    string
+	Help    string
+	Labels  []string
+	Buckets []float64 // Only set for histograms
+
+	series map[string]*Series
+}
+
+// Series holds the value of a metric for one combination of labels
+type Series struct {
+	LabelValues []string
+
+	// Counters
+	Value float64
+
+	// Histograms, Buckets holds a count for each bucket of the metric
+	// plus one for the observations that exceed the largest bucket.
+	Buckets []uint64
+	Count   uint64
+	Sum     float64
+}
+
+type Counter struct {
+	*Metric
+}
+
+type Histogram struct {
+	*Metric
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	return &Counter{register(name, help, nil, labels)}
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{register(name, help, buckets, labels)}
+}
+
+func register(name, help string, buckets []float64, labels []string) *Metric {
+	m := &Metric{
+		Name:    name,
+		Help:    help,
+		Labels:  labels,
+		Buckets: buckets,
+		series:  make(map[string]*Series),
+	}
+
+	registered.Lock()
+	defer registered.Unlock()
+	if _, ok := registered.byName[name]; ok {
+		panic("Metric '" + name + "' was registered twice")
+	}
+	registered.byName[name] = m
+
+	return m
+}
+
+// All returns all metrics, ordered by name
+func All() []*Metric {
+	registered.RLock()
+	defer registered.RUnlock()
+
+	all := make([]*Metric, 0, len(registered.byName))
+	for _, m := range registered.byName {
+		all = append(all, m)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Name < all[j].Name
+	})
+
+	return all
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(v float64, labelValues ...string) {
+	c.Lock()
+	defer c.Unlock()
+
+	if s := c.getSeries(labelValues); s != nil {
+		s.Value += v
+	}
+}
+
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	h.Lock()
+	defer h.Unlock()
+
+	s := h.getSeries(labelValues)
+	if s == nil {
+		return
+	}
+
+	s.Buckets[sort.SearchFloat64s(h.Buckets, v)]++
+	s.Count++
+	s.Sum += v
+}
+
+func (m *Metric) IsHistogram() bool {
+	return m.Buckets != nil
+}
+
+// Snapshot returns a copy of all series of the metric
+func (m *Metric) Snapshot() []*Series {
+	m.Lock()
+	defer m.Unlock()
+
+	series := make([]*Series, 0, len(m.series))
+	for _, s := range m.series {
+		c := *s
+		c.Buckets = append([]uint64(nil), s.Buckets...)
+		series = append(series, &c)
+	}
+
+	return series
+}
+
+// getSeries returns the series for the given label values, creating
+// it if needed. Must be called with the lock held. Returns nil if the
+// label values don't match the metric, or if there are too many series.
+func (m *Metric) getSeries(labelValues []string) *Series {
+	if len(labelValues) != len(m.Labels) {
+		return nil
+	}
+
+	key := seriesKey(labelValues)
+	if s, ok := m.series[key]; ok {
+		return s
+	}
+
+	if len(m.series) >= maxSeriesPerMetric {
+		return nil
+	}
+
+	s := &Series{LabelValues: append([]string(nil), labelValues...)}
+	if m.IsHistogram() {
+		s.Buckets = make([]uint64, len(m.Buckets)+1)
+	}
+	m.series[key] = s
+
+	return s
+}
+
+func seriesKey(labelValues []string) string {
+	key := ""
+	for _, v := range labelValues {
+		key += v + "\xff"
+	}
+
+	return key
+}
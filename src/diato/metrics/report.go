@@ -0,0 +1,99 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package metrics
+
+import (
+	"fmt"
+
+	pb "diato/pb"
+)
+
+// Drain returns all series recorded since the previous call to Drain()
+// and resets them. If the report could not be delivered, it should be
+// handed back to Merge() so nothing is lost.
+func Drain() *pb.MetricsReport {
+	report := &pb.MetricsReport{
+		Metrics: make([]*pb.Metric, 0),
+	}
+
+	for _, m := range All() {
+		m.Lock()
+		for _, s := range m.series {
+			report.Metrics = append(report.Metrics, &pb.Metric{
+				Name:        m.Name,
+				LabelValues: s.LabelValues,
+				Value:       s.Value,
+				Buckets:     s.Buckets,
+				Count:       s.Count,
+				Sum:         s.Sum,
+			})
+		}
+		m.series = make(map[string]*Series)
+		m.Unlock()
+	}
+
+	return report
+}
+
+// Merge adds the series of the report to the ones recorded locally
+func Merge(report *pb.MetricsReport) error {
+	registered.RLock()
+	defer registered.RUnlock()
+
+	for _, in := range report.Metrics {
+		m, ok := registered.byName[in.Name]
+		if !ok {
+			return fmt.Errorf("Unknown metric '%s'", in.Name)
+		}
+
+		if err := m.merge(in); err != nil {
+			return fmt.Errorf("Could not merge metric '%s': %s", in.Name, err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (m *Metric) merge(in *pb.Metric) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if len(in.LabelValues) != len(m.Labels) {
+		return fmt.Errorf("Expected %d label values, got %d", len(m.Labels), len(in.LabelValues))
+	}
+
+	expectedBuckets := 0
+	if m.IsHistogram() {
+		expectedBuckets = len(m.Buckets) + 1
+	}
+	if len(in.Buckets) != expectedBuckets {
+		return fmt.Errorf("Expected %d buckets, got %d", expectedBuckets, len(in.Buckets))
+	}
+
+	s := m.getSeries(in.LabelValues)
+	if s == nil {
+		return nil // Too many series, dropped just like local ones would be
+	}
+
+	s.Value += in.Value
+	for i, count := range in.Buckets {
+		s.Buckets[i] += count
+	}
+	s.Count += in.Count
+	s.Sum += in.Sum
+
+	return nil
+}
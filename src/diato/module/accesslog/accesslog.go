@@ -0,0 +1,25 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package accesslog writes a line for each request once its response
+// has been sent. Workers format the lines and hand them to the server,
+// which writes them to stdout, a file or syslog.
+package accesslog
+
+import (
+	_ "diato/module/accesslog/server"
+	_ "diato/module/accesslog/worker"
+)
@@ -0,0 +1,75 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+type Config struct {
+	Enabled bool
+
+	// One of 'combined', 'json' or 'template'
+	Format   string
+	Template string
+
+	// One of 'stdout', 'file' or 'syslog'
+	Output    string
+	Path      string
+	SyslogTag string `gcfg:"syslog-tag"`
+}
+
+// Check validates the configuration without opening anything. All
+// problems found are returned, rather than just the first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	switch c.Format {
+	case "combined", "json":
+	case "template":
+		if c.Template == "" {
+			errs = append(errs, errors.New("Format is 'template', but no template was set"))
+		} else if _, err := template.New("").Parse(c.Template); err != nil {
+			errs = append(errs, fmt.Errorf("Could not parse template: %s", err.Error()))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("Unknown format '%s', expected one of combined, json or template", c.Format))
+	}
+
+	switch c.Output {
+	case "stdout", "syslog":
+	case "file":
+		if c.Path == "" {
+			errs = append(errs, errors.New("Output is 'file', but no path was set"))
+			break
+		}
+		dir := filepath.Dir(c.Path)
+		if fileinfo, err := os.Stat(dir); err != nil || !fileinfo.IsDir() {
+			errs = append(errs, fmt.Errorf("Directory '%s' does not exist", dir))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("Unknown output '%s', expected one of stdout, file or syslog", c.Output))
+	}
+
+	return errs
+}
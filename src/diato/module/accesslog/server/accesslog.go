@@ -0,0 +1,188 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package accesslog
+
+import (
+	"fmt"
+	"log"
+	"log/syslog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"diato/config"
+	"diato/server"
+	"diato/util/stop"
+)
+
+const name = "accesslog"
+
+func init() {
+	server.RegisterModule(newModule)
+}
+
+type module struct {
+	enabled bool
+
+	sync.Mutex
+	out output
+}
+
+// output is where the lines end up
+type output interface {
+	writeLine(line string) error
+	reopen() error
+}
+
+func newModule(s *server.Server, config *config.Config) ([]server.Module, error) {
+	module := &module{
+		enabled: config.AccessLog.Enabled,
+	}
+
+	if !module.Enabled() {
+		return []server.Module{module}, nil
+	}
+
+	var err error
+	switch config.AccessLog.Output {
+	case "stdout":
+		module.out = &writerOutput{os.Stdout}
+	case "file":
+		module.out, err = newFileOutput(config.AccessLog.Path)
+	case "syslog":
+		module.out, err = newSyslogOutput(config.AccessLog.SyslogTag)
+	default:
+		err = fmt.Errorf("Unknown output '%s'", config.AccessLog.Output)
+	}
+	if err != nil {
+		return []server.Module{}, fmt.Errorf("Could not open access log: %s", err.Error())
+	}
+
+	module.reopenOnSignal()
+	return []server.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) write(lines []string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for _, line := range lines {
+		if err := m.out.writeLine(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reopenOnSignal reopens the output upon SIGUSR1,
+// so the log file can be rotated by e.g. logrotate.
+func (m *module) reopenOnSignal() {
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGUSR1)
+
+	stopper := stop.NewStopper(func() {
+		signal.Stop(signalCh)
+	})
+
+	go func() {
+		for {
+			select {
+			case <-signalCh:
+				m.Lock()
+				if err := m.out.reopen(); err != nil {
+					log.Printf("Could not reopen access log: %s", err.Error())
+				} else {
+					log.Print("Reopened access log")
+				}
+				m.Unlock()
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	}()
+}
+
+type writerOutput struct {
+	w *os.File
+}
+
+func (o *writerOutput) writeLine(line string) error {
+	_, err := fmt.Fprintln(o.w, line)
+	return err
+}
+
+func (o *writerOutput) reopen() error {
+	return nil
+}
+
+type fileOutput struct {
+	writerOutput
+	path string
+}
+
+func newFileOutput(path string) (*fileOutput, error) {
+	o := &fileOutput{path: path}
+	if err := o.reopen(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+func (o *fileOutput) reopen() error {
+	file, err := os.OpenFile(o.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+
+	if o.w != nil {
+		o.w.Close()
+	}
+	o.w = file
+
+	return nil
+}
+
+type syslogOutput struct {
+	w *syslog.Writer
+}
+
+func newSyslogOutput(tag string) (*syslogOutput, error) {
+	w, err := syslog.New(syslog.LOG_INFO|syslog.LOG_DAEMON, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &syslogOutput{w}, nil
+}
+
+func (o *syslogOutput) writeLine(line string) error {
+	return o.w.Info(line)
+}
+
+func (o *syslogOutput) reopen() error {
+	return nil
+}
@@ -0,0 +1,172 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package accesslog
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"diato/config"
+	pb "diato/module/accesslog/pb"
+	"diato/util/stop"
+	"diato/worker"
+)
+
+const name = "accesslog"
+
+const (
+	// Lines are sent to the server in batches of at most this size...
+	batchSize = 512
+
+	// ...or whatever was collected within this interval
+	batchInterval = 250 * time.Millisecond
+
+	// Lines are dropped if this many are waiting to be sent
+	queueSize = 8192
+
+	// How long stopping waits for the queued lines to be sent
+	flushTimeout = 10 * time.Second
+)
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled bool
+	format  formatter
+
+	lines   chan string
+	dropped uint64
+
+	grpc pb.ModuleAccessLogClient
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.AccessLog.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	format, err := newFormatter(config.AccessLog.Format, config.AccessLog.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	module := &module{
+		enabled: true,
+		format:  format,
+		lines:   make(chan string, queueSize),
+		grpc:    pb.NewModuleAccessLogClient(w.GetGrpcClientConn()),
+	}
+
+	go module.sendLoop()
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) PostResponse(req *http.Request) {
+	line, err := m.format(newEntry(req))
+	if err != nil {
+		log.Printf("Could not format access log line: %s", err.Error())
+		return
+	}
+
+	select {
+	case m.lines <- line:
+	default:
+		atomic.AddUint64(&m.dropped, 1)
+	}
+}
+
+func (m *module) sendLoop() {
+	flushed := make(chan struct{})
+	defer close(flushed)
+
+	ticker := time.NewTicker(batchInterval)
+	stopper := stop.NewStopper(func() {
+		ticker.Stop()
+
+		// The worker exits once everything was stopped
+		select {
+		case <-flushed:
+		case <-time.After(flushTimeout):
+		}
+	})
+
+	batch := make([]string, 0, batchSize)
+	for {
+		select {
+		case line := <-m.lines:
+			batch = append(batch, line)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+		case <-stopper.ShouldStop():
+			m.flush(batch)
+			return
+		}
+
+		if dropped := atomic.SwapUint64(&m.dropped, 0); dropped > 0 {
+			log.Printf("Dropped %d access log lines because the server could not keep up", dropped)
+		}
+
+		if len(batch) == 0 {
+			continue
+		}
+
+		m.send(batch)
+		batch = make([]string, 0, batchSize)
+	}
+}
+
+// flush sends the batch along with the lines that are queued, so those
+// aren't lost when the worker stops. Lines queued meanwhile are not
+// waited for.
+func (m *module) flush(batch []string) {
+	for n := len(m.lines); n > 0; n-- {
+		batch = append(batch, <-m.lines)
+		if len(batch) == batchSize {
+			m.send(batch)
+			batch = make([]string, 0, batchSize)
+		}
+	}
+
+	if len(batch) > 0 {
+		m.send(batch)
+	}
+}
+
+func (m *module) send(batch []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, err := m.grpc.Write(ctx, &pb.Lines{Lines: batch}); err != nil {
+		log.Printf("Could not send %d access log lines to the server: %s", len(batch), err.Error())
+	}
+}
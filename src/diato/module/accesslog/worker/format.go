@@ -0,0 +1,160 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package accesslog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"diato/worker"
+)
+
+// entry holds everything that can end up in the access log. Its
+// fields are what's available to user-defined templates, e.g.:
+// '{{.RemoteAddr}} {{.Host}} "{{.Method}} {{.Uri}}" {{.Status}}'
+type entry struct {
+	Time       time.Time `json:"time"`
+	RequestId  string    `json:"request_id"`
+	RemoteAddr string    `json:"remote_addr"`
+	Host       string    `json:"host"`
+	Method     string    `json:"method"`
+	Uri        string    `json:"uri"`
+	Proto      string    `json:"proto"`
+	Status     int       `json:"status"`
+	Referer    string    `json:"referer"`
+	UserAgent  string    `json:"user_agent"`
+
+	// Only populated if GeoIP is enabled
+	Country string `json:"country,omitempty"`
+	Asn     uint32 `json:"asn,omitempty"`
+
+	BytesReceived int64 `json:"bytes_received"`
+	BytesSent     int64 `json:"bytes_sent"`
+
+	// In seconds
+	Duration            float64 `json:"duration"`
+	UpstreamConnectTime float64 `json:"upstream_connect_time"`
+	UpstreamTtfb        float64 `json:"upstream_ttfb"`
+}
+
+type formatter func(*entry) (string, error)
+
+func newEntry(req *http.Request) *entry {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+
+	remoteAddr, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		remoteAddr = req.RemoteAddr
+	}
+
+	geoIp := ctxInfo.GeoIp()
+
+	return &entry{
+		Time:       ctxInfo.TimeStart(),
+		RequestId:  ctxInfo.RequestIdString(),
+		RemoteAddr: remoteAddr,
+		Host:       req.Host,
+		Method:     req.Method,
+		Uri:        req.RequestURI,
+		Proto:      req.Proto,
+		Status:     ctxInfo.ResponseStatus(),
+		Referer:    req.Referer(),
+		UserAgent:  req.UserAgent(),
+
+		Country: geoIp.GetCountryIsoCode(),
+		Asn:     geoIp.GetAsn(),
+
+		BytesReceived: ctxInfo.BytesReceived(),
+		BytesSent:     ctxInfo.BytesSent(),
+
+		Duration:            ctxInfo.Duration().Seconds(),
+		UpstreamConnectTime: ctxInfo.UpstreamConnectTime().Seconds(),
+		UpstreamTtfb:        ctxInfo.UpstreamTtfb().Seconds(),
+	}
+}
+
+func newFormatter(format, tmpl string) (formatter, error) {
+	switch format {
+	case "combined":
+		return formatCombined, nil
+	case "json":
+		return formatJson, nil
+	case "template":
+		return newTemplateFormatter(tmpl)
+	}
+
+	return nil, fmt.Errorf("Unknown access log format '%s'", format)
+}
+
+// formatCombined formats the entry in the Combined Log Format,
+// as used by Apache and Nginx.
+func formatCombined(e *entry) (string, error) {
+	bytesSent := "-"
+	if e.BytesSent > 0 {
+		bytesSent = fmt.Sprintf("%d", e.BytesSent)
+	}
+
+	return fmt.Sprintf("%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\"",
+		e.RemoteAddr,
+		e.Time.Format("02/Jan/2006:15:04:05 -0700"),
+		clfEscape(e.Method), clfEscape(e.Uri), clfEscape(e.Proto),
+		e.Status,
+		bytesSent,
+		clfEscape(e.Referer),
+		clfEscape(e.UserAgent),
+	), nil
+}
+
+func formatJson(e *entry) (string, error) {
+	line, err := json.Marshal(e)
+	return string(line), err
+}
+
+func newTemplateFormatter(tmpl string) (formatter, error) {
+	t, err := template.New("accesslog").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse access log template: %s", err.Error())
+	}
+
+	return func(e *entry) (string, error) {
+		buf := &bytes.Buffer{}
+		if err := t.Execute(buf, e); err != nil {
+			return "", err
+		}
+
+		// Each entry must remain on a line of its own
+		return strings.Replace(buf.String(), "\n", " ", -1), nil
+	}, nil
+}
+
+// clfEscape makes sure the value can't break out of its quotes, or
+// mess with the line. Empty values are logged as '-'.
+func clfEscape(value string) string {
+	if value == "" {
+		return "-"
+	}
+
+	value = strings.Replace(value, "\\", "\\\\", -1)
+	value = strings.Replace(value, "\"", "\\\"", -1)
+	value = strings.Replace(value, "\n", "\\n", -1)
+	return value
+}
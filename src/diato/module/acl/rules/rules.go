@@ -0,0 +1,156 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rules parses the file that determines which clients may
+// access which paths. Each line holds a host, a path prefix, the
+// methods it applies to, an action and one or more CIDRs, e.g.:
+//
+//	# <host> <path prefix> <methods> <allow|deny> <cidr> [<cidr> ...]
+//	example.com  /wp-admin  *         allow  192.0.2.0/24 2001:db8::/32
+//	example.com  /wp-admin  *         deny   all
+//	example.com  /api       PUT,POST  deny   198.51.100.7
+//	*            /admin     *         allow  192.0.2.10
+//	*            /admin     *         deny   all
+//
+// The rules of a host are evaluated in order, followed by those of
+// '*'. The first rule that applies to the request and has a match
+// decides; if none does, the client is allowed. Path prefixes match
+// whole segments, '/admin' applies to '/admin/users' but not to
+// '/administrator'.
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	pb "diato/module/acl/pb"
+	"diato/util/hostname"
+)
+
+func ParseFile(path string) (*pb.Rules, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read file %s: %s", path, err.Error())
+	}
+
+	rules, err := Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse file %s: %s", path, err.Error())
+	}
+
+	return rules, nil
+}
+
+func Parse(contents []byte) (*pb.Rules, error) {
+	rules := &pb.Rules{
+		Rules: make([]*pb.Rule, 0),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		rule, err := parseRule(fields)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func parseRule(fields []string) (*pb.Rule, error) {
+	if len(fields) < 5 {
+		return nil, errors.New("Expected a host, a path prefix, methods, an action and at least one CIDR")
+	}
+
+	rule := &pb.Rule{
+		Host:       hostname.Normalize(fields[0]),
+		PathPrefix: fields[1],
+		Methods:    make([]string, 0),
+	}
+
+	if !strings.HasPrefix(rule.PathPrefix, "/") {
+		return nil, fmt.Errorf("Path prefix '%s' must start with a '/'", rule.PathPrefix)
+	}
+
+	if fields[2] != "*" {
+		for _, method := range strings.Split(fields[2], ",") {
+			if method == "" {
+				return nil, fmt.Errorf("Invalid methods '%s'", fields[2])
+			}
+			rule.Methods = append(rule.Methods, strings.ToUpper(method))
+		}
+	}
+
+	switch fields[3] {
+	case "allow":
+		rule.Allow = true
+	case "deny":
+	default:
+		return nil, fmt.Errorf("Unknown action '%s', expected allow or deny", fields[3])
+	}
+
+	for _, match := range fields[4:] {
+		if match == "all" {
+			rule.All = true
+			continue
+		}
+
+		cidr, err := ParseCidr(match)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid CIDR '%s', expected all, an IP or a CIDR", match)
+		}
+		rule.Cidrs = append(rule.Cidrs, cidr.String())
+	}
+
+	return rule, nil
+}
+
+// ParseCidr parses a CIDR, or a single IP address as if it was one
+func ParseCidr(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("Invalid IP address '%s'", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, cidr, err := net.ParseCIDR(s)
+	return cidr, err
+}
@@ -0,0 +1,100 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	pb "diato/module/acl/pb"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []*pb.Rule // nil if parsing should fail
+	}{
+		{
+			name:     "empty",
+			contents: "\n# Nothing but comments\n\n",
+			want:     []*pb.Rule{},
+		},
+		{
+			name:     "allow cidrs",
+			contents: "example.com /wp-admin * allow 192.0.2.0/24 2001:db8::/32",
+			want: []*pb.Rule{{
+				Host: "example.com", PathPrefix: "/wp-admin", Methods: []string{}, Allow: true,
+				Cidrs: []string{"192.0.2.0/24", "2001:db8::/32"},
+			}},
+		},
+		{
+			name:     "deny all",
+			contents: "example.com /wp-admin * deny all",
+			want: []*pb.Rule{{
+				Host: "example.com", PathPrefix: "/wp-admin", Methods: []string{}, All: true,
+			}},
+		},
+		{
+			name:     "methods and single addresses",
+			contents: "Example.COM. /api put,POST deny 198.51.100.7 2001:db8::1 # No writes",
+			want: []*pb.Rule{{
+				Host: "example.com", PathPrefix: "/api", Methods: []string{"PUT", "POST"},
+				Cidrs: []string{"198.51.100.7/32", "2001:db8::1/128"},
+			}},
+		},
+		{
+			name:     "cidrs are masked",
+			contents: "* /admin * allow 192.0.2.10/24",
+			want: []*pb.Rule{{
+				Host: "*", PathPrefix: "/admin", Methods: []string{}, Allow: true,
+				Cidrs: []string{"192.0.2.0/24"},
+			}},
+		},
+		{
+			name:     "order is kept",
+			contents: "* /admin * allow 192.0.2.10\n* /admin * deny all",
+			want: []*pb.Rule{
+				{Host: "*", PathPrefix: "/admin", Methods: []string{}, Allow: true, Cidrs: []string{"192.0.2.10/32"}},
+				{Host: "*", PathPrefix: "/admin", Methods: []string{}, All: true},
+			},
+		},
+		{name: "too few fields", contents: "example.com /admin * deny"},
+		{name: "relative path", contents: "example.com admin * deny all"},
+		{name: "empty method", contents: "example.com /admin GET,,POST deny all"},
+		{name: "unknown action", contents: "example.com /admin * block all"},
+		{name: "invalid cidr", contents: "example.com /admin * deny 192.0.2.0/33"},
+		{name: "invalid address", contents: "example.com /admin * deny example.org"},
+	}
+
+	for _, test := range tests {
+		got, err := Parse([]byte(test.contents))
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got rules %v", test.name, got.Rules)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: could not parse: %s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got.Rules, test.want) {
+			t.Errorf("%s: got rules %v, want %v", test.name, got.Rules, test.want)
+		}
+	}
+}
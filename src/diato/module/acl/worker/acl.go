@@ -0,0 +1,105 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package acl
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+
+	"diato/config"
+	pb "diato/module/acl/pb"
+	"diato/util/rulesync"
+	"diato/worker"
+)
+
+const name = "acl"
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled bool
+
+	rules *rulesync.Poller // *ruleSet
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.Acl.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	module := &module{
+		enabled: true,
+	}
+
+	grpc := pb.NewModuleAclClient(w.GetGrpcClientConn())
+	var err error
+	module.rules, err = rulesync.NewPoller("ACL rules", func(ctx context.Context, version uint64) (rulesync.Rules, error) {
+		return grpc.GetRules(ctx, &pb.RulesRequest{Version: version})
+	}, func(res rulesync.Rules) (interface{}, int, error) {
+		rules := res.(*pb.Rules)
+		set, err := newRuleSet(rules)
+		return set, len(rules.Rules), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) ProcessRequest(req *http.Request) {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+
+	var ip net.IP
+	if addr, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		ip = net.ParseIP(addr)
+	}
+
+	set := m.rules.Current().(*ruleSet)
+	if !set.allowed(ctxInfo.Host(), cleanPath(req.URL.Path), req.Method, ip) {
+		ctxInfo.Intervene(&worker.Intervention{Status: http.StatusForbidden})
+	}
+}
+
+// cleanPath makes sure rules can't be bypassed with
+// paths like '//admin' or '/foo/../admin'.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+
+	return cleaned
+}
@@ -0,0 +1,112 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package acl
+
+import (
+	"fmt"
+	"net"
+
+	pb "diato/module/acl/pb"
+	"diato/module/acl/rules"
+	"diato/util/urlpath"
+)
+
+// ruleSet holds the rules as received from the server,
+// in a form that allows for matching them quickly.
+type ruleSet struct {
+	// By host, in the order they're to be evaluated
+	byHost map[string][]*rule
+}
+
+type rule struct {
+	pathPrefix string
+	methods    map[string]bool // Empty if the rule applies to all methods
+	allow      bool
+
+	all  bool
+	nets []*net.IPNet
+}
+
+func newRuleSet(res *pb.Rules) (*ruleSet, error) {
+	set := &ruleSet{
+		byHost: make(map[string][]*rule),
+	}
+
+	for _, r := range res.Rules {
+		compiled := &rule{
+			pathPrefix: r.PathPrefix,
+			methods:    make(map[string]bool),
+			allow:      r.Allow,
+			all:        r.All,
+			nets:       make([]*net.IPNet, 0, len(r.Cidrs)),
+		}
+
+		for _, method := range r.Methods {
+			compiled.methods[method] = true
+		}
+		for _, cidr := range r.Cidrs {
+			ipNet, err := rules.ParseCidr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse rule for host '%s': %s", r.Host, err.Error())
+			}
+			compiled.nets = append(compiled.nets, ipNet)
+		}
+
+		set.byHost[r.Host] = append(set.byHost[r.Host], compiled)
+	}
+
+	return set, nil
+}
+
+// allowed tells if the client may make the request. The rules of the
+// host are evaluated first, followed by those that apply to all hosts.
+func (s *ruleSet) allowed(host, path, method string, ip net.IP) bool {
+	for _, h := range []string{host, "*"} {
+		for _, r := range s.byHost[h] {
+			if r.appliesTo(path, method) && r.matches(ip) {
+				return r.allow
+			}
+		}
+	}
+
+	return true
+}
+
+func (r *rule) appliesTo(path, method string) bool {
+	if !urlpath.HasPrefix(path, r.pathPrefix) {
+		return false
+	}
+
+	return len(r.methods) == 0 || r.methods[method]
+}
+
+func (r *rule) matches(ip net.IP) bool {
+	if r.all {
+		return true
+	}
+
+	if ip == nil {
+		return false
+	}
+
+	for _, ipNet := range r.nets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
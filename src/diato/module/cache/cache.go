@@ -0,0 +1,28 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache caches responses of backends in the workers, honoring
+// Cache-Control, Vary and revalidation through ETag or Last-Modified.
+// Each worker keeps its own cache in memory, optionally backed by a
+// larger one on disk within the chroot. Responses can be purged by
+// host or URL through the admin API, which the server passes on to
+// the workers.
+package cache
+
+import (
+	_ "diato/module/cache/server"
+	_ "diato/module/cache/worker"
+)
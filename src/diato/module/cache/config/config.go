@@ -0,0 +1,100 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+type Config struct {
+	Enabled bool
+
+	// In megabytes and kilobytes, zero means the default applies
+	MemorySize    int `gcfg:"memory-size"`
+	MaxObjectSize int `gcfg:"max-object-size"`
+
+	// Relative to the chroot. Responses evicted from memory are
+	// kept here, if set. In megabytes, zero means the default.
+	DiskPath string `gcfg:"disk-path"`
+	DiskSize int    `gcfg:"disk-size"`
+}
+
+// Sizes holds the sizes of the cache in bytes, with the defaults applied
+type Sizes struct {
+	Memory    int64
+	MaxObject int64
+	Disk      int64
+}
+
+func (c *Config) Sizes() *Sizes {
+	sizes := &Sizes{
+		Memory:    256 << 20,
+		MaxObject: 1 << 20,
+		Disk:      4096 << 20,
+	}
+
+	if c.MemorySize > 0 {
+		sizes.Memory = int64(c.MemorySize) << 20
+	}
+	if c.MaxObjectSize > 0 {
+		sizes.MaxObject = int64(c.MaxObjectSize) << 10
+	}
+	if c.DiskSize > 0 {
+		sizes.Disk = int64(c.DiskSize) << 20
+	}
+
+	return sizes
+}
+
+// DiskDir returns the directory of the disk cache as seen from within
+// the chroot, which is empty if there's none. It can't escape the chroot.
+func (c *Config) DiskDir() string {
+	if c.DiskPath == "" {
+		return ""
+	}
+
+	return filepath.Clean("/" + c.DiskPath)
+}
+
+// Check validates the configuration. All problems found are
+// returned, rather than just the first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if c.MemorySize < 0 || c.MaxObjectSize < 0 || c.DiskSize < 0 {
+		errs = append(errs, errors.New("memory-size, max-object-size and disk-size must not be negative"))
+	}
+
+	sizes := c.Sizes()
+	if sizes.MaxObject > sizes.Memory {
+		errs = append(errs, fmt.Errorf("max-object-size (%d KB) must not exceed memory-size (%d MB)",
+			sizes.MaxObject>>10, sizes.Memory>>20))
+	}
+
+	if c.DiskPath != "" && !filepath.IsAbs(c.DiskPath) {
+		errs = append(errs, fmt.Errorf("disk-path '%s' must be absolute, relative to the chroot", c.DiskPath))
+	}
+	if c.DiskPath != "" && c.DiskDir() == "/" {
+		errs = append(errs, errors.New("disk-path must be a directory within the chroot, not the chroot itself"))
+	}
+
+	return errs
+}
@@ -0,0 +1,149 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"diato/config"
+	pb "diato/module/cache/pb"
+	"diato/server"
+	"diato/util/hostname"
+)
+
+const name = "cache"
+
+// The number of purges kept for workers that have yet to apply them.
+// Workers ask every second, so this only runs out if many are sent.
+const maxPurges = 1024
+
+func init() {
+	server.RegisterModule(newModule)
+}
+
+type module struct {
+	enabled bool
+
+	sync.Mutex
+	sequence uint64
+	purges   []*pb.Purge
+}
+
+func newModule(s *server.Server, config *config.Config) ([]server.Module, error) {
+	module := &module{
+		enabled: config.Cache.Enabled,
+		purges:  make([]*pb.Purge, 0),
+	}
+
+	if !module.Enabled() || config.Cache.DiskDir() == "" {
+		return []server.Module{module}, nil
+	}
+
+	dir := filepath.Join(config.General.Chroot, config.Cache.DiskDir())
+	if err := prepareDiskDir(dir); err != nil {
+		return []server.Module{}, err
+	}
+
+	return []server.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+// prepareDiskDir creates the directory of the disk cache, in which
+// each worker creates its own. Those of previous workers are removed,
+// none of them are running yet.
+func prepareDiskDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("Could not create cache directory '%s': %s", dir, err.Error())
+	}
+	if err := os.Chown(dir, server.WorkerUid, server.WorkerGid); err != nil {
+		return fmt.Errorf("Could not set owner of cache directory '%s': %s", dir, err.Error())
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("Could not read cache directory '%s': %s", dir, err.Error())
+	}
+
+	for _, file := range files {
+		if _, err := strconv.Atoi(file.Name()); err != nil || !file.IsDir() {
+			continue // Not ours
+		}
+		if err := os.RemoveAll(filepath.Join(dir, file.Name())); err != nil {
+			log.Printf("Could not remove previous cache directory: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+func (m *module) purge(host, url string) *pb.Purge {
+	m.Lock()
+	defer m.Unlock()
+
+	m.sequence++
+	purge := &pb.Purge{
+		Sequence: m.sequence,
+		Host:     hostname.Normalize(host),
+		Url:      url,
+	}
+
+	m.purges = append(m.purges, purge)
+	if len(m.purges) > maxPurges {
+		m.purges = m.purges[len(m.purges)-maxPurges:]
+	}
+
+	return purge
+}
+
+// getPurges returns the purges after the given sequence
+func (m *module) getPurges(after uint64) *pb.Purges {
+	m.Lock()
+	defer m.Unlock()
+
+	res := &pb.Purges{
+		Sequence: m.sequence,
+		Purges:   make([]*pb.Purge, 0),
+	}
+	if after >= m.sequence {
+		return res
+	}
+
+	if len(m.purges) > 0 && m.purges[0].Sequence > after+1 {
+		res.All = true
+		return res
+	}
+
+	for _, purge := range m.purges {
+		if purge.Sequence > after {
+			res.Purges = append(res.Purges, purge)
+		}
+	}
+
+	return res
+}
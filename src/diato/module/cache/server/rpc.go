@@ -0,0 +1,63 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	pb "diato/module/cache/pb"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+func (m *module) RegisterRpcEndpoints(s *grpc.Server) {
+	pb.RegisterModuleCacheServer(s, &rpcServer{m})
+}
+
+func (m *module) RegisterAdminEndpoints(s *grpc.Server) {
+	pb.RegisterModuleCacheAdminServer(s, &rpcAdminServer{m})
+}
+
+type rpcServer struct {
+	module *module
+}
+
+func (s *rpcServer) GetPurges(ctx context.Context, in *pb.PurgesRequest) (*pb.Purges, error) {
+	if !s.module.Enabled() {
+		return nil, errors.New("The cache module was not enabled")
+	}
+
+	return s.module.getPurges(in.After), nil
+}
+
+type rpcAdminServer struct {
+	module *module
+}
+
+func (s *rpcAdminServer) PurgeCache(ctx context.Context, in *pb.PurgeRequest) (*pb.Purge, error) {
+	if in.Host == "" {
+		return nil, errors.New("No host was given")
+	}
+	if in.Url != "" && !strings.HasPrefix(in.Url, "/") {
+		return nil, errors.New("The url must start with a '/'")
+	}
+
+	log.Printf("Purging cache of host '%s' url '%s' as requested through the admin API", in.Host, in.Url)
+	return s.module.purge(in.Host, in.Url), nil
+}
@@ -0,0 +1,145 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"diato/config"
+	pb "diato/module/cache/pb"
+	"diato/util/stop"
+	"diato/worker"
+)
+
+const name = "cache"
+
+// How often the server is asked for purges
+const purgeInterval = 1 * time.Second
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled bool
+
+	store         *store
+	maxObjectSize int64
+
+	grpc          pb.ModuleCacheClient
+	purgeSequence uint64
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.Cache.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	sizes := config.Cache.Sizes()
+	var disk *diskStore
+	if dir := config.Cache.DiskDir(); dir != "" {
+		var err error
+		if disk, err = newDiskStore(dir, sizes.Disk); err != nil {
+			return nil, err
+		}
+	}
+
+	module := &module{
+		enabled:       true,
+		store:         newStore(newMemoryStore(sizes.Memory), disk),
+		maxObjectSize: sizes.MaxObject,
+		grpc:          pb.NewModuleCacheClient(w.GetGrpcClientConn()),
+	}
+
+	// Purges sent before we started don't concern us
+	purges, err := module.getPurges()
+	if err != nil {
+		return nil, err
+	}
+	module.purgeSequence = purges.Sequence
+
+	go module.purgeLoop()
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) WrapTransport(next http.RoundTripper) http.RoundTripper {
+	return &transport{
+		next:          next,
+		store:         m.store,
+		maxObjectSize: m.maxObjectSize,
+		revalidating:  make(map[string]bool),
+	}
+}
+
+func (m *module) purgeLoop() {
+	ticker := time.NewTicker(purgeInterval)
+	stopper := stop.NewStopper(func() {
+		ticker.Stop()
+		if m.store.disk != nil {
+			m.store.disk.close()
+		}
+	})
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.purge(); err != nil {
+				log.Printf("Could not fetch cache purges: %s", err.Error())
+			}
+		case <-stopper.ShouldStop():
+			return
+		}
+	}
+}
+
+// purge applies the purges the server received since we last asked
+func (m *module) purge() error {
+	purges, err := m.getPurges()
+	if err != nil {
+		return err
+	}
+
+	if purges.All {
+		log.Print("Purging the entire cache, as purges were missed")
+		m.store.purge("", "")
+	}
+	for _, purge := range purges.Purges {
+		m.store.purge(purge.Host, purge.Url)
+	}
+
+	m.purgeSequence = purges.Sequence
+	return nil
+}
+
+func (m *module) getPurges() (*pb.Purges, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), purgeInterval)
+	defer cancel()
+
+	return m.grpc.GetPurges(ctx, &pb.PurgesRequest{After: m.purgeSequence})
+}
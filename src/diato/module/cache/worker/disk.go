@@ -0,0 +1,271 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// The number of entries waiting to be written to disk. When
+// the disk can't keep up, entries are dropped instead.
+const diskQueueSize = 256
+
+// diskStore holds the entries evicted from memory on disk. Only an
+// index is kept in memory, the entries are read when needed.
+type diskStore struct {
+	sync.Mutex
+
+	dir     string
+	maxSize int64
+	size    int64
+
+	items map[string]*list.Element
+	lru   *list.List                 // Of *diskItem, most recently used first
+	byUri map[string]map[string]bool // Keys by uriKey()
+
+	// Bumped on every purge, entries queued before are dropped
+	generation uint64
+	queue      chan *diskWrite
+	closed     bool
+}
+
+type diskWrite struct {
+	entry      *entry
+	generation uint64
+}
+
+type diskItem struct {
+	key  string
+	host string
+	uri  string
+	size int64
+}
+
+// newDiskStore sets up a store in a directory of its own within
+// the given one, so workers don't get in each other's way.
+func newDiskStore(parent string, maxSize int64) (*diskStore, error) {
+	removeStaleDirs(parent)
+
+	dir := filepath.Join(parent, strconv.Itoa(os.Getpid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("Could not create cache directory '%s': %s", dir, err.Error())
+	}
+
+	s := &diskStore{
+		dir:     dir,
+		maxSize: maxSize,
+		items:   make(map[string]*list.Element),
+		lru:     list.New(),
+		byUri:   make(map[string]map[string]bool),
+		queue:   make(chan *diskWrite, diskQueueSize),
+	}
+
+	go s.writeLoop()
+	return s, nil
+}
+
+// removeStaleDirs removes the directories of workers that are
+// no longer running, e.g. because they were recycled.
+func removeStaleDirs(parent string) {
+	files, err := ioutil.ReadDir(parent)
+	if err != nil {
+		log.Printf("Could not read cache directory '%s': %s", parent, err.Error())
+		return
+	}
+
+	for _, file := range files {
+		pid, err := strconv.Atoi(file.Name())
+		if err != nil || !file.IsDir() {
+			continue
+		}
+		if err := syscall.Kill(pid, 0); err == syscall.ESRCH {
+			os.RemoveAll(filepath.Join(parent, file.Name()))
+		}
+	}
+}
+
+func (s *diskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:]))
+}
+
+func (s *diskStore) get(key string) *entry {
+	s.Lock()
+	element, ok := s.items[key]
+	if ok {
+		s.lru.MoveToFront(element)
+	}
+	s.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		s.remove(key)
+		return nil
+	}
+	defer file.Close()
+
+	e := &entry{}
+	if err := gob.NewDecoder(file).Decode(e); err != nil || e.Key != key {
+		s.remove(key)
+		return nil
+	}
+
+	return e
+}
+
+// set queues the entry to be written to disk
+func (s *diskStore) set(e *entry) {
+	s.Lock()
+	write := &diskWrite{e, s.generation}
+	s.Unlock()
+
+	select {
+	case s.queue <- write:
+	default:
+	}
+}
+
+func (s *diskStore) writeLoop() {
+	for write := range s.queue {
+		if err := s.write(write.entry, write.generation); err != nil {
+			log.Printf("Could not write to cache directory: %s", err.Error())
+		}
+	}
+}
+
+func (s *diskStore) write(e *entry, generation uint64) error {
+	s.Lock()
+	closed := s.closed
+	s.Unlock()
+	if closed {
+		return nil
+	}
+
+	file, err := ioutil.TempFile(s.dir, ".tmp")
+	if err != nil {
+		return err
+	}
+
+	err = gob.NewEncoder(file).Encode(e)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), s.path(e.Key))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed || s.generation != generation {
+		os.Remove(s.path(e.Key)) // Purged in the meantime
+		return nil
+	}
+
+	s.removeLocked(e.Key, false)
+	s.items[e.Key] = s.lru.PushFront(&diskItem{e.Key, e.Host, e.Uri, e.size()})
+	s.size += e.size()
+	addUriKey(s.byUri, e.Host, e.Uri, e.Key)
+
+	for s.size > s.maxSize {
+		s.removeLocked(s.lru.Back().Value.(*diskItem).key, true)
+	}
+
+	return nil
+}
+
+func (s *diskStore) remove(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.removeLocked(key, true)
+}
+
+// removeUri removes the entries of the uri of the host
+func (s *diskStore) removeUri(host, uri string) {
+	s.Lock()
+	defer s.Unlock()
+
+	for key := range s.byUri[uriKey(host, uri)] {
+		s.removeLocked(key, true)
+	}
+}
+
+// dropQueued drops the entries that are waiting to be written
+func (s *diskStore) dropQueued() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.generation++
+}
+
+// removeIf removes all entries the given function returns true for
+func (s *diskStore) removeIf(match func(host, uri string) bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.generation++
+	for key, element := range s.items {
+		item := element.Value.(*diskItem)
+		if match(item.host, item.uri) {
+			s.removeLocked(key, true)
+		}
+	}
+}
+
+func (s *diskStore) removeLocked(key string, removeFile bool) {
+	element, ok := s.items[key]
+	if !ok {
+		return
+	}
+
+	item := element.Value.(*diskItem)
+	s.lru.Remove(element)
+	delete(s.items, key)
+	s.size -= item.size
+	removeUriKey(s.byUri, item.host, item.uri, key)
+	if removeFile {
+		os.Remove(s.path(key))
+	}
+}
+
+// close removes the directory of the store, it's only of use to us
+func (s *diskStore) close() {
+	s.Lock()
+	defer s.Unlock()
+
+	s.closed = true
+	os.RemoveAll(s.dir)
+}
@@ -0,0 +1,235 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// entry is a stored response. Its fields are exported so it
+// can be written to disk, the type itself is not.
+type entry struct {
+	Key  string
+	Host string
+	Uri  string
+
+	// If set, this entry only tells which request headers the response
+	// varies on. The response is stored under a key including those.
+	Vary []string
+
+	Status int
+	Header http.Header
+	Body   []byte
+
+	StoredAt             time.Time
+	InitialAge           time.Duration
+	FreshFor             time.Duration
+	StaleWhileRevalidate time.Duration
+	MustRevalidate       bool
+}
+
+func newEntry(key, host, uri string, res *http.Response, p *policy, now time.Time) *entry {
+	return &entry{
+		Key:    key,
+		Host:   host,
+		Uri:    uri,
+		Status: res.StatusCode,
+		Header: cloneHeader(res.Header),
+
+		StoredAt:             now,
+		InitialAge:           initialAge(res.Header),
+		FreshFor:             p.freshFor,
+		StaleWhileRevalidate: p.staleWhileRevalidate,
+		MustRevalidate:       p.mustRevalidate,
+	}
+}
+
+// newVaryEntry returns the entry that's stored under the key of
+// the URL, telling which request headers the response varies on.
+func newVaryEntry(key, host, uri string, header http.Header) *entry {
+	vary := make([]string, 0)
+	for _, value := range header["Vary"] {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				vary = append(vary, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	if len(vary) == 0 {
+		return nil
+	}
+
+	return &entry{Key: key, Host: host, Uri: uri, Vary: vary}
+}
+
+// variantKey returns the key the variant of a response to the
+// request is stored under, given the headers it varies on.
+func variantKey(key string, vary []string, req *http.Request) string {
+	buf := bytes.NewBufferString(key)
+	for _, name := range vary {
+		fmt.Fprintf(buf, "\n%s: %s", name, strings.Join(req.Header[name], ", "))
+	}
+
+	return buf.String()
+}
+
+// size approximates the memory the entry takes up
+func (e *entry) size() int64 {
+	size := int64(len(e.Key)+len(e.Host)+len(e.Uri)+len(e.Body)) + 256
+	for name, values := range e.Header {
+		size += int64(len(name))
+		for _, value := range values {
+			size += int64(len(value))
+		}
+	}
+
+	return size
+}
+
+func (e *entry) age(now time.Time) time.Duration {
+	return e.InitialAge + now.Sub(e.StoredAt)
+}
+
+func (e *entry) isFresh(now time.Time) bool {
+	return e.age(now) < e.FreshFor
+}
+
+// canServeStale tells if the entry may be served while it's
+// revalidated in the background.
+func (e *entry) canServeStale(now time.Time) bool {
+	return !e.MustRevalidate && e.age(now) < e.FreshFor+e.StaleWhileRevalidate
+}
+
+func (e *entry) hasValidators() bool {
+	return e.Header.Get("ETag") != "" || e.Header.Get("Last-Modified") != ""
+}
+
+// addValidators makes the request conditional, so the backend
+// can tell the entry is still current rather than resending it.
+func (e *entry) addValidators(req *http.Request) {
+	if etag := e.Header.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := e.Header.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// revalidated returns a copy of the entry with the headers of the
+// 304 response merged in, or nil if it may no longer be stored.
+func (e *entry) revalidated(res *http.Response, now time.Time) *entry {
+	header := cloneHeader(e.Header)
+	for name, values := range res.Header {
+		switch name {
+		case "Content-Length", "Content-Encoding", "Transfer-Encoding":
+			continue // Those describe the body we did not receive
+		}
+		header[name] = values
+	}
+
+	p, ok := newPolicy(e.Status, header)
+	if !ok {
+		return nil
+	}
+
+	return &entry{
+		Key:    e.Key,
+		Host:   e.Host,
+		Uri:    e.Uri,
+		Status: e.Status,
+		Header: header,
+		Body:   e.Body,
+
+		StoredAt:             now,
+		InitialAge:           initialAge(res.Header),
+		FreshFor:             p.freshFor,
+		StaleWhileRevalidate: p.staleWhileRevalidate,
+		MustRevalidate:       p.mustRevalidate,
+	}
+}
+
+// response returns the entry as a response to the request. If the
+// client already has it, as told by its conditional headers, it
+// gets a 304 instead.
+func (e *entry) response(req *http.Request, cacheStatus string, now time.Time) *http.Response {
+	header := cloneHeader(e.Header)
+	header.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	header.Set("X-Cache", cacheStatus)
+
+	status, body := e.Status, e.Body
+	if e.Status == http.StatusOK && e.isNotModified(req) {
+		status, body = http.StatusNotModified, nil
+		header.Del("Content-Length")
+	} else {
+		header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// isNotModified evaluates the conditional headers of the request
+// against the entry. If-None-Match takes precedence, if present.
+func (e *entry) isNotModified(req *http.Request) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		etag := strings.TrimPrefix(e.Header.Get("ETag"), "W/")
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims, err := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	lastModified, err := http.ParseTime(e.Header.Get("Last-Modified"))
+	if err != nil {
+		return false
+	}
+
+	return !lastModified.After(ims)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	clone := make(http.Header, len(h))
+	for name, values := range h {
+		clone[name] = append([]string(nil), values...)
+	}
+
+	return clone
+}
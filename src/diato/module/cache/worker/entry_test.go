@@ -0,0 +1,64 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestIsNotModified(t *testing.T) {
+	const (
+		modified = "Mon, 02 Jan 2006 15:04:05 GMT"
+		before   = "Mon, 02 Jan 2006 14:04:05 GMT"
+		after    = "Mon, 02 Jan 2006 16:04:05 GMT"
+	)
+
+	tests := []struct {
+		name   string
+		stored http.Header
+		req    http.Header
+		want   bool
+	}{
+		{"etag matches", http.Header{"Etag": {`"v1"`}}, http.Header{"If-None-Match": {`"v1"`}}, true},
+		{"etag in list", http.Header{"Etag": {`"v1"`}}, http.Header{"If-None-Match": {`"v0", "v1"`}}, true},
+		{"etag differs", http.Header{"Etag": {`"v1"`}}, http.Header{"If-None-Match": {`"v2"`}}, false},
+		{"weak etags", http.Header{"Etag": {`W/"v1"`}}, http.Header{"If-None-Match": {`"v1"`}}, true},
+		{"weak candidate", http.Header{"Etag": {`"v1"`}}, http.Header{"If-None-Match": {`W/"v1"`}}, true},
+		{"any etag", http.Header{"Etag": {`"v1"`}}, http.Header{"If-None-Match": {"*"}}, true},
+		{"no stored etag", http.Header{"Last-Modified": {modified}}, http.Header{"If-None-Match": {"*"}}, false},
+		{
+			"if-none-match takes precedence",
+			http.Header{"Etag": {`"v1"`}, "Last-Modified": {modified}},
+			http.Header{"If-None-Match": {`"v2"`}, "If-Modified-Since": {after}},
+			false,
+		},
+		{"not modified since", http.Header{"Last-Modified": {modified}}, http.Header{"If-Modified-Since": {modified}}, true},
+		{"not modified since later", http.Header{"Last-Modified": {modified}}, http.Header{"If-Modified-Since": {after}}, true},
+		{"modified since", http.Header{"Last-Modified": {modified}}, http.Header{"If-Modified-Since": {before}}, false},
+		{"invalid date", http.Header{"Last-Modified": {modified}}, http.Header{"If-Modified-Since": {"yesterday"}}, false},
+		{"no last-modified", http.Header{"Etag": {`"v1"`}}, http.Header{"If-Modified-Since": {after}}, false},
+		{"unconditional", http.Header{"Etag": {`"v1"`}, "Last-Modified": {modified}}, http.Header{}, false},
+	}
+
+	for _, test := range tests {
+		e := &entry{Status: http.StatusOK, Header: test.stored}
+		req := &http.Request{Method: "GET", Header: test.req}
+		if got := e.isNotModified(req); got != test.want {
+			t.Errorf("%s: got %t, want %t", test.name, got, test.want)
+		}
+	}
+}
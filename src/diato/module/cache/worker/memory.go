@@ -0,0 +1,119 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// memoryStore holds entries in memory, evicting those
+// used least recently once it has grown too large.
+type memoryStore struct {
+	sync.Mutex
+
+	maxSize int64
+	size    int64
+
+	entries map[string]*list.Element
+	lru     *list.List                 // Of *entry, most recently used first
+	byUri   map[string]map[string]bool // Keys by uriKey()
+}
+
+func newMemoryStore(maxSize int64) *memoryStore {
+	return &memoryStore{
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+		byUri:   make(map[string]map[string]bool),
+	}
+}
+
+func (s *memoryStore) get(key string) *entry {
+	s.Lock()
+	defer s.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+
+	s.lru.MoveToFront(element)
+	return element.Value.(*entry)
+}
+
+// set stores the entry, returning those evicted to make room for it
+func (s *memoryStore) set(e *entry) []*entry {
+	s.Lock()
+	defer s.Unlock()
+
+	s.removeLocked(e.Key)
+	s.entries[e.Key] = s.lru.PushFront(e)
+	s.size += e.size()
+	addUriKey(s.byUri, e.Host, e.Uri, e.Key)
+
+	evicted := make([]*entry, 0)
+	for s.size > s.maxSize {
+		oldest := s.lru.Back().Value.(*entry)
+		s.removeLocked(oldest.Key)
+		evicted = append(evicted, oldest)
+	}
+
+	return evicted
+}
+
+func (s *memoryStore) remove(key string) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.removeLocked(key)
+}
+
+// removeUri removes the entries of the uri of the host, which
+// may be more than one if it's served by more backends or varies.
+func (s *memoryStore) removeUri(host, uri string) {
+	s.Lock()
+	defer s.Unlock()
+
+	for key := range s.byUri[uriKey(host, uri)] {
+		s.removeLocked(key)
+	}
+}
+
+// removeIf removes all entries the given function returns true for
+func (s *memoryStore) removeIf(match func(e *entry) bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	for key, element := range s.entries {
+		if match(element.Value.(*entry)) {
+			s.removeLocked(key)
+		}
+	}
+}
+
+func (s *memoryStore) removeLocked(key string) {
+	element, ok := s.entries[key]
+	if !ok {
+		return
+	}
+
+	e := element.Value.(*entry)
+	s.lru.Remove(element)
+	delete(s.entries, key)
+	s.size -= e.size()
+	removeUriKey(s.byUri, e.Host, e.Uri, key)
+}
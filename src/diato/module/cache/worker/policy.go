@@ -0,0 +1,189 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The statuses of responses that may be cached, provided
+// they carry explicit freshness information.
+var cacheableStatus = map[int]bool{
+	200: true, 203: true, 204: true, 300: true, 301: true, 308: true,
+	404: true, 405: true, 410: true, 414: true, 501: true,
+}
+
+// policy determines how long a response may be served from the cache
+type policy struct {
+	freshFor             time.Duration
+	staleWhileRevalidate time.Duration
+
+	// Once stale, it must be revalidated before it's served again
+	mustRevalidate bool
+}
+
+// parseCacheControl returns the directives of the Cache-Control header,
+// with their values if any. Directive names are case insensitive.
+func parseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "" {
+				continue
+			}
+
+			name, arg := directive, ""
+			if i := strings.Index(directive, "="); i != -1 {
+				name, arg = directive[:i], strings.Trim(directive[i+1:], "\" ")
+			}
+			directives[strings.ToLower(strings.TrimSpace(name))] = arg
+		}
+	}
+
+	return directives
+}
+
+func parseSeconds(value string) (time.Duration, bool) {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return time.Duration(seconds) * time.Second, true
+}
+
+// isCacheableRequest tells if the request may be answered
+// from the cache, and its response be stored in it.
+func isCacheableRequest(req *http.Request) bool {
+	if req.Method != "GET" {
+		return false
+	}
+
+	// Responses to these differ per client, or are partial
+	if req.Header.Get("Authorization") != "" || req.Header.Get("Range") != "" {
+		return false
+	}
+
+	_, noStore := parseCacheControl(req.Header)["no-store"]
+	return !noStore
+}
+
+// requiresRevalidation tells if the client insists on the
+// backend confirming a stored response is still current.
+func requiresRevalidation(req *http.Request) bool {
+	cc := parseCacheControl(req.Header)
+	if _, ok := cc["no-cache"]; ok {
+		return true
+	}
+	if maxAge, ok := cc["max-age"]; ok && maxAge == "0" {
+		return true
+	}
+
+	return req.Header.Get("Pragma") == "no-cache"
+}
+
+// isUnsafeMethod tells if the request may change what the backend
+// responds to the same URL, so its stored responses are invalidated.
+func isUnsafeMethod(method string) bool {
+	switch method {
+	case "GET", "HEAD", "OPTIONS", "TRACE":
+		return false
+	}
+
+	return true
+}
+
+// newPolicy returns the policy of a response. If it may not be
+// stored, the second return value is false. Only responses with
+// explicit freshness information or validators are stored.
+func newPolicy(status int, header http.Header) (*policy, bool) {
+	if !cacheableStatus[status] {
+		return nil, false
+	}
+
+	cc := parseCacheControl(header)
+	if _, ok := cc["no-store"]; ok {
+		return nil, false
+	}
+	if _, ok := cc["private"]; ok {
+		return nil, false
+	}
+
+	// Never hand one client's cookies to another
+	if len(header["Set-Cookie"]) > 0 {
+		return nil, false
+	}
+	for _, vary := range header["Vary"] {
+		if strings.TrimSpace(vary) == "*" {
+			return nil, false
+		}
+	}
+	if strings.HasPrefix(header.Get("Content-Type"), "text/event-stream") {
+		return nil, false
+	}
+
+	p := &policy{}
+	explicit := false
+	if sMaxAge, ok := parseSeconds(cc["s-maxage"]); ok {
+		p.freshFor, explicit = sMaxAge, true
+		p.mustRevalidate = true // As s-maxage implies proxy-revalidate
+	} else if maxAge, ok := parseSeconds(cc["max-age"]); ok {
+		p.freshFor, explicit = maxAge, true
+	} else if expires := header.Get("Expires"); expires != "" {
+		explicit = true // Invalid dates mean it has expired already
+		if expiresAt, err := http.ParseTime(expires); err == nil {
+			date, err := http.ParseTime(header.Get("Date"))
+			if err != nil {
+				date = time.Now()
+			}
+			if expiresAt.After(date) {
+				p.freshFor = expiresAt.Sub(date)
+			}
+		}
+	}
+
+	_, noCache := cc["no-cache"]
+	_, mustRevalidate := cc["must-revalidate"]
+	_, proxyRevalidate := cc["proxy-revalidate"]
+	if noCache {
+		p.freshFor = 0
+	}
+	p.mustRevalidate = p.mustRevalidate || noCache || mustRevalidate || proxyRevalidate
+
+	hasValidators := header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+	if !explicit && !(noCache && hasValidators) {
+		return nil, false
+	}
+	if p.freshFor == 0 && !hasValidators {
+		return nil, false // Could never be served
+	}
+
+	if swr, ok := parseSeconds(cc["stale-while-revalidate"]); ok && !p.mustRevalidate {
+		p.staleWhileRevalidate = swr
+	}
+
+	return p, true
+}
+
+// initialAge returns the age the response had when we received it
+func initialAge(header http.Header) time.Duration {
+	age, _ := parseSeconds(header.Get("Age"))
+	return age
+}
@@ -0,0 +1,196 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   *policy // nil if the response may not be stored
+	}{
+		{
+			name:   "max-age",
+			status: 200,
+			header: http.Header{"Cache-Control": {"public, max-age=60"}},
+			want:   &policy{freshFor: time.Minute},
+		},
+		{
+			name:   "directives are case insensitive",
+			status: 200,
+			header: http.Header{"Cache-Control": {`Max-Age="60"`}},
+			want:   &policy{freshFor: time.Minute},
+		},
+		{
+			name:   "s-maxage wins and implies revalidation",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=60, s-maxage=120"}},
+			want:   &policy{freshFor: 2 * time.Minute, mustRevalidate: true},
+		},
+		{
+			name:   "expires",
+			status: 200,
+			header: http.Header{
+				"Date":    {"Mon, 02 Jan 2006 15:04:05 GMT"},
+				"Expires": {"Mon, 02 Jan 2006 16:04:05 GMT"},
+			},
+			want: &policy{freshFor: time.Hour},
+		},
+		{
+			name:   "invalid expires with validator",
+			status: 200,
+			header: http.Header{"Expires": {"0"}, "Etag": {`"v1"`}},
+			want:   &policy{},
+		},
+		{
+			name:   "no-cache with validator",
+			status: 200,
+			header: http.Header{"Cache-Control": {"no-cache"}, "Etag": {`"v1"`}},
+			want:   &policy{mustRevalidate: true},
+		},
+		{
+			name:   "stale-while-revalidate",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=0, stale-while-revalidate=30"}, "Last-Modified": {"Mon, 02 Jan 2006 15:04:05 GMT"}},
+			want:   &policy{staleWhileRevalidate: 30 * time.Second},
+		},
+		{
+			name:   "must-revalidate disables stale-while-revalidate",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=60, must-revalidate, stale-while-revalidate=30"}},
+			want:   &policy{freshFor: time.Minute, mustRevalidate: true},
+		},
+		{
+			name:   "cacheable error",
+			status: 404,
+			header: http.Header{"Cache-Control": {"max-age=60"}},
+			want:   &policy{freshFor: time.Minute},
+		},
+		{
+			name:   "uncacheable status",
+			status: 500,
+			header: http.Header{"Cache-Control": {"max-age=60"}},
+		},
+		{
+			name:   "no-store",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=60, no-store"}},
+		},
+		{
+			name:   "private",
+			status: 200,
+			header: http.Header{"Cache-Control": {"private, max-age=60"}},
+		},
+		{
+			name:   "set-cookie",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Set-Cookie": {"session=secret"}},
+		},
+		{
+			name:   "vary on everything",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Vary": {" * "}},
+		},
+		{
+			name:   "event stream",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=60"}, "Content-Type": {"text/event-stream; charset=utf-8"}},
+		},
+		{
+			name:   "no freshness information",
+			status: 200,
+			header: http.Header{"Etag": {`"v1"`}},
+		},
+		{
+			name:   "never fresh without validators",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=0"}},
+		},
+		{
+			name:   "negative max-age",
+			status: 200,
+			header: http.Header{"Cache-Control": {"max-age=-1"}},
+		},
+	}
+
+	for _, test := range tests {
+		got, ok := newPolicy(test.status, test.header)
+		if test.want == nil {
+			if ok {
+				t.Errorf("%s: got policy %+v, want it not to be stored", test.name, got)
+			}
+			continue
+		}
+
+		if !ok {
+			t.Errorf("%s: not stored, want policy %+v", test.name, test.want)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got policy %+v, want %+v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestIsCacheableRequest(t *testing.T) {
+	tests := []struct {
+		method string
+		header http.Header
+		want   bool
+	}{
+		{"GET", http.Header{}, true},
+		{"GET", http.Header{"Cache-Control": {"no-cache"}}, true},
+		{"HEAD", http.Header{}, false},
+		{"POST", http.Header{}, false},
+		{"GET", http.Header{"Authorization": {"Basic Zm9vOmJhcg=="}}, false},
+		{"GET", http.Header{"Range": {"bytes=0-99"}}, false},
+		{"GET", http.Header{"Cache-Control": {"No-Store"}}, false},
+	}
+
+	for _, test := range tests {
+		req := &http.Request{Method: test.method, Header: test.header}
+		if got := isCacheableRequest(req); got != test.want {
+			t.Errorf("isCacheableRequest(%s %v) = %t, want %t", test.method, test.header, got, test.want)
+		}
+	}
+}
+
+func TestRequiresRevalidation(t *testing.T) {
+	tests := []struct {
+		header http.Header
+		want   bool
+	}{
+		{http.Header{}, false},
+		{http.Header{"Cache-Control": {"no-cache"}}, true},
+		{http.Header{"Cache-Control": {"max-age=0"}}, true},
+		{http.Header{"Cache-Control": {"max-age=60"}}, false},
+		{http.Header{"Pragma": {"no-cache"}}, true},
+	}
+
+	for _, test := range tests {
+		req := &http.Request{Method: "GET", Header: test.header}
+		if got := requiresRevalidation(req); got != test.want {
+			t.Errorf("requiresRevalidation(%v) = %t, want %t", test.header, got, test.want)
+		}
+	}
+}
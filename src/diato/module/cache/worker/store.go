@@ -0,0 +1,159 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"net/http"
+	"sync"
+)
+
+// store holds the entries in memory, moving those evicted from it to
+// disk if there's a disk store. Entries found on disk are moved back.
+type store struct {
+	memory *memoryStore
+	disk   *diskStore
+
+	// Bumped on every purge, so responses that were requested
+	// before are not stored once they have been received.
+	sync.Mutex
+	generation uint64
+}
+
+func newStore(memory *memoryStore, disk *diskStore) *store {
+	return &store{
+		memory: memory,
+		disk:   disk,
+	}
+}
+
+// lookup returns the entry that's stored for the request, if any
+func (s *store) lookup(key string, req *http.Request) *entry {
+	e := s.get(key)
+	if e != nil && e.Vary != nil {
+		e = s.get(variantKey(key, e.Vary, req))
+	}
+
+	return e
+}
+
+func (s *store) get(key string) *entry {
+	if e := s.memory.get(key); e != nil {
+		return e
+	}
+	if s.disk == nil {
+		return nil
+	}
+
+	e := s.disk.get(key)
+	if e != nil {
+		s.setMemory(e)
+	}
+	return e
+}
+
+func (s *store) currentGeneration() uint64 {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.generation
+}
+
+// set stores the entry, unless a purge happened since the given
+// generation. The response may be outdated in that case.
+func (s *store) set(e *entry, generation uint64) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.generation == generation {
+		s.setMemory(e)
+	}
+}
+
+func (s *store) setMemory(e *entry) {
+	for _, evicted := range s.memory.set(e) {
+		if s.disk != nil {
+			s.disk.set(evicted)
+		}
+	}
+}
+
+func (s *store) remove(key string) {
+	s.memory.remove(key)
+	if s.disk != nil {
+		s.disk.remove(key)
+	}
+}
+
+// invalidate removes the entries of the uri of the host, e.g. after
+// a request changed what it refers to. Contrary to purge() only the
+// entries of the uri are affected, everything else is left alone.
+func (s *store) invalidate(host, uri string) {
+	s.memory.removeUri(host, uri)
+	if s.disk != nil {
+		s.disk.removeUri(host, uri)
+	}
+}
+
+// purge removes the entries of the given host, only those of the
+// given uri if it's not empty. If the host is empty, all are removed.
+// Responses that are underway are not stored, they may be outdated.
+func (s *store) purge(host, uri string) {
+	s.Lock()
+	s.generation++
+	s.Unlock()
+
+	if host != "" && uri != "" {
+		s.invalidate(host, uri)
+		if s.disk != nil {
+			s.disk.dropQueued()
+		}
+		return
+	}
+
+	match := func(entryHost, entryUri string) bool {
+		return host == "" || (entryHost == host && (uri == "" || entryUri == uri))
+	}
+
+	s.memory.removeIf(func(e *entry) bool {
+		return match(e.Host, e.Uri)
+	})
+	if s.disk != nil {
+		s.disk.removeIf(match)
+	}
+}
+
+// uriKey returns the key entries are indexed by for purging, the
+// host and uri the client requested. One uri may have several
+// entries, e.g. for the variants of a response.
+func uriKey(host, uri string) string {
+	return host + " " + uri
+}
+
+func addUriKey(index map[string]map[string]bool, host, uri, key string) {
+	k := uriKey(host, uri)
+	if index[k] == nil {
+		index[k] = make(map[string]bool)
+	}
+	index[k][key] = true
+}
+
+func removeUriKey(index map[string]map[string]bool, host, uri, key string) {
+	k := uriKey(host, uri)
+	delete(index[k], key)
+	if len(index[k]) == 0 {
+		delete(index, k)
+	}
+}
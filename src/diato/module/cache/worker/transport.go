@@ -0,0 +1,248 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"diato/util/hostname"
+	"diato/worker"
+)
+
+// Set on responses to tell whether they came from the cache
+const cacheStatusHeader = "X-Cache"
+
+// transport answers requests from the cache where it can, passing the
+// others on to the backend and storing their responses if allowed.
+type transport struct {
+	next          http.RoundTripper
+	store         *store
+	maxObjectSize int64
+
+	sync.Mutex
+	revalidating map[string]bool
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isCacheableRequest(req) {
+		res, err := t.next.RoundTrip(req)
+		if err == nil && isUnsafeMethod(req.Method) && res.StatusCode < 400 {
+			t.store.invalidate(clientUri(req))
+		}
+		return res, err
+	}
+
+	now := time.Now()
+	generation := t.store.currentGeneration()
+	key := cacheKey(req)
+
+	e := t.store.lookup(key, req)
+	if e != nil && !requiresRevalidation(req) {
+		if e.isFresh(now) {
+			return e.response(req, "HIT", now), nil
+		}
+		if e.canServeStale(now) {
+			t.revalidateInBackground(req, key, e)
+			return e.response(req, "STALE", now), nil
+		}
+	}
+
+	// Unless the client has validators of its own, which
+	// may be for another version than the one we have.
+	outreq := req
+	conditional := e != nil && e.hasValidators() && !hasConditionals(req)
+	if conditional {
+		outreq = cloneRequest(req, req.Context())
+		e.addValidators(outreq)
+	}
+
+	res, err := t.next.RoundTrip(outreq)
+	if err != nil {
+		return nil, err
+	}
+
+	if conditional && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+		return t.revalidated(req, e, res, generation, now).response(req, "REVALIDATED", now), nil
+	}
+
+	host, uri := clientUri(req)
+	return t.storeResponse(req, key, host, uri, res, generation, now), nil
+}
+
+// cacheKey returns the key responses to the request are stored under.
+// It includes the backend, as paths may have been stripped for it.
+func cacheKey(req *http.Request) string {
+	return hostname.Normalize(req.Host) + " " + req.URL.Host + " " + req.URL.RequestURI()
+}
+
+// clientUri returns the host and uri the client requested, which is
+// what purges through the admin API refer to. The path may have been
+// rewritten or stripped of a prefix since.
+func clientUri(req *http.Request) (string, string) {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+	return ctxInfo.Host(), ctxInfo.RequestUri()
+}
+
+// revalidated updates the entry after the backend confirmed it's
+// still current, and returns it. It's removed if it may no longer
+// be stored, but still returned as it's current.
+func (t *transport) revalidated(req *http.Request, e *entry, res *http.Response, generation uint64, now time.Time) *entry {
+	updated := e.revalidated(res, now)
+	if updated == nil {
+		t.store.remove(e.Key)
+		return e
+	}
+
+	t.store.set(updated, generation)
+	return updated
+}
+
+// storeResponse stores the response once its body has been read
+// completely, provided it may be stored and is not too large.
+func (t *transport) storeResponse(req *http.Request, key, host, uri string, res *http.Response, generation uint64, now time.Time) *http.Response {
+	p, ok := newPolicy(res.StatusCode, res.Header)
+	if !ok || res.ContentLength > t.maxObjectSize {
+		res.Header.Set(cacheStatusHeader, "MISS")
+		return res
+	}
+
+	vary := newVaryEntry(key, host, uri, res.Header)
+	if vary != nil {
+		key = variantKey(key, vary.Vary, req)
+	}
+
+	e := newEntry(key, host, uri, res, p, now)
+	res.Header.Set(cacheStatusHeader, "MISS")
+	res.Body = &captureBody{
+		ReadCloser: res.Body,
+		limit:      t.maxObjectSize,
+		done: func(body []byte) {
+			e.Body = body
+			if vary != nil {
+				t.store.set(vary, generation)
+			}
+			t.store.set(e, generation)
+		},
+	}
+
+	return res
+}
+
+// revalidateInBackground fetches the entry from the backend again,
+// while the client is served the stale one. Only once at a time.
+func (t *transport) revalidateInBackground(req *http.Request, key string, e *entry) {
+	t.Lock()
+	if t.revalidating[e.Key] {
+		t.Unlock()
+		return
+	}
+	t.revalidating[e.Key] = true
+	t.Unlock()
+
+	// The client's request is done long before this one
+	outreq := cloneRequest(req, context.Background())
+	for _, name := range conditionalHeaders {
+		outreq.Header.Del(name)
+	}
+	if e.hasValidators() {
+		e.addValidators(outreq)
+	}
+
+	go func() {
+		defer func() {
+			t.Lock()
+			delete(t.revalidating, e.Key)
+			t.Unlock()
+		}()
+
+		now := time.Now()
+		generation := t.store.currentGeneration()
+		res, err := t.next.RoundTrip(outreq)
+		if err != nil {
+			log.Printf("Could not revalidate cached response of %s%s: %s", e.Host, e.Uri, err.Error())
+			return
+		}
+		defer res.Body.Close()
+
+		if res.StatusCode == http.StatusNotModified && e.hasValidators() {
+			t.revalidated(outreq, e, res, generation, now)
+			return
+		}
+
+		res = t.storeResponse(outreq, key, e.Host, e.Uri, res, generation, now)
+		io.Copy(ioutil.Discard, io.LimitReader(res.Body, t.maxObjectSize+1))
+	}()
+}
+
+var conditionalHeaders = []string{
+	"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "If-Range",
+}
+
+func hasConditionals(req *http.Request) bool {
+	for _, name := range conditionalHeaders {
+		if req.Header.Get(name) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cloneRequest returns a copy of the request with its own headers
+func cloneRequest(req *http.Request, ctx context.Context) *http.Request {
+	clone := req.WithContext(ctx)
+	clone.Header = cloneHeader(req.Header)
+
+	return clone
+}
+
+// captureBody keeps a copy of the body as it's read, handing it to
+// done once all of it was. Bodies over the limit are not kept.
+type captureBody struct {
+	io.ReadCloser
+
+	buf      bytes.Buffer
+	limit    int64
+	exceeded bool
+	done     func(body []byte)
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if !b.exceeded {
+		if int64(b.buf.Len()+n) > b.limit {
+			b.exceeded = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+
+	if err == io.EOF && !b.exceeded && b.done != nil {
+		b.done(b.buf.Bytes())
+		b.done = nil
+	}
+
+	return n, err
+}
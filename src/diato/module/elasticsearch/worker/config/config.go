@@ -16,8 +16,44 @@
 
 package config
 
+import (
+	"errors"
+	"fmt"
+	"net/url"
+)
+
 type Config struct {
 	Enabled bool
 	Url     []string
 	Sniff   bool
 }
+
+// Check validates the configuration without connecting to any of
+// the nodes. All problems found are returned, rather than just the
+// first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if len(c.Url) == 0 {
+		errs = append(errs, errors.New("No url was set"))
+	}
+
+	for _, rawUrl := range c.Url {
+		u, err := url.Parse(rawUrl)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not parse url '%s': %s", rawUrl, err.Error()))
+			continue
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			errs = append(errs, fmt.Errorf("Url '%s' must use either http or https", rawUrl))
+		}
+		if u.Host == "" {
+			errs = append(errs, fmt.Errorf("Url '%s' does not contain a host", rawUrl))
+		}
+	}
+
+	return errs
+}
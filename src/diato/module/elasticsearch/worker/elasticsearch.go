@@ -74,6 +74,6 @@ func (m *module) Name() string {
 	return name
 }
 
-func (m *module) PostModifyResponse(req *http.Request, resp *http.Response) {
-	m.persistRequest(req, resp)
+func (m *module) PostResponse(req *http.Request) {
+	m.persistRequest(req)
 }
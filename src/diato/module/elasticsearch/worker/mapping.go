@@ -19,23 +19,23 @@ package elasticsearch
 import (
 	"net/http"
 
-	"diato/module/elasticsearch/worker/mapping/v1"
+	"diato/module/elasticsearch/worker/mapping/v2"
 )
 
 type mapping interface {
-	PersistRequest(*http.Request, *http.Response)
+	PersistRequest(*http.Request)
 	EnsureTemplate() error
 }
 
 func (m *module) getActiveMapping() mapping {
 	if m.activeMapping == nil {
-		m.activeMapping = v1.NewMapping(m.client)
+		m.activeMapping = v2.NewMapping(m.client)
 	}
 	return m.activeMapping
 }
 
-func (m *module) persistRequest(req *http.Request, resp *http.Response) {
-	m.getActiveMapping().PersistRequest(req, resp)
+func (m *module) persistRequest(req *http.Request) {
+	m.getActiveMapping().PersistRequest(req)
 }
 
 func (m *module) ensureTemplate() error {
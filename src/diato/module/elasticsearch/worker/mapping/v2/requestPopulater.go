@@ -14,21 +14,20 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package v1
+package v2
 
 import (
 	"fmt"
 	"net"
 	"net/http"
 	"os"
-	"time"
 
 	"diato/worker"
 
 	"github.com/blang/semver"
 )
 
-func (m *mapping) getStructuredRequest(req *http.Request, resp *http.Response) *httpRequest {
+func (m *mapping) getStructuredRequest(req *http.Request) *httpRequest {
 	ctx := req.Context()
 	ctxInfo := ctx.Value("diato").(*worker.ContextInfo)
 
@@ -49,17 +48,24 @@ func (m *mapping) getStructuredRequest(req *http.Request, resp *http.Response) *
 		Path:      req.URL.Path,
 		Query:     req.URL.RawQuery,
 		Timestamp: ctxInfo.TimeStart(),
-		Duration:  time.Now().Sub(ctxInfo.TimeStart()).Seconds(),
+		Duration:  ctxInfo.Duration().Seconds(),
 		SLD:       ctxInfo.Sld(),
 
+		Body_sent:     bodySize{ctxInfo.BytesSent()},
+		Body_received: bodySize{ctxInfo.BytesReceived()},
+		Upstream: upstreamInfo{
+			ConnectTime:     ctxInfo.UpstreamConnectTime().Seconds(),
+			TimeToFirstByte: ctxInfo.UpstreamTtfb().Seconds(),
+		},
+
 		RemoteIp: remoteAddr,
 		//Local_ip:      localAddr,
-		ResponseCode: resp.StatusCode,
+		ResponseCode: ctxInfo.ResponseStatus(),
 		Method:       req.Method,
-		// TODO: Geoip
-		HttpVersion: float32(req.ProtoMajor) + (float32(req.ProtoMinor) / 10),
-		Referrer:    req.Referer(),
-		UserAgent:   m.getUserAgent(req),
+		Geoip:        getGeoIp(ctxInfo),
+		HttpVersion:  float32(req.ProtoMajor) + (float32(req.ProtoMinor) / 10),
+		Referrer:     req.Referer(),
+		UserAgent:    m.getUserAgent(req),
 
 		Diato: diatoInfo{
 			Hostname: hostname,
@@ -67,6 +73,30 @@ func (m *mapping) getStructuredRequest(req *http.Request, resp *http.Response) *
 	}
 }
 
+func getGeoIp(ctxInfo *worker.ContextInfo) *geoIp {
+	info := ctxInfo.GeoIp()
+	if info == nil {
+		return nil
+	}
+
+	g := &geoIp{
+		ContinentName:  info.ContinentName,
+		CountryIsoCode: info.CountryIsoCode,
+		CountryName:    info.CountryName,
+		RegionName:     info.RegionName,
+		CityName:       info.CityName,
+		Asn:            info.Asn,
+		AsOrganization: info.AsOrganization,
+	}
+
+	// Only the city database holds a location
+	if info.Latitude != 0 || info.Longitude != 0 {
+		g.Location = &geoLocation{Lat: info.Latitude, Lon: info.Longitude}
+	}
+
+	return g
+}
+
 func (m *mapping) getUserAgent(req *http.Request) userAgent {
 	ctx := req.Context()
 	ctxInfo := ctx.Value("diato").(*worker.ContextInfo)
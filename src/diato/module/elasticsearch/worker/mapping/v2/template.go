@@ -14,15 +14,15 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package v1
+package v2
 
 var template = `{
-   "template":"diato-httprequest-*-v1",
+   "template":"diato-httprequest-*-v2",
    "aliases":{
       "diato-httprequest":{
 
       },
-      "diato-httprequest-v1":{
+      "diato-httprequest-v2":{
 
       }
    },
@@ -71,6 +71,30 @@ var template = `{
             "Duration":{
                "type":"float"
             },
+            "Body_sent":{
+               "properties":{
+                  "Bytes":{
+                     "type":"long"
+                  }
+               }
+            },
+            "Body_received":{
+               "properties":{
+                  "Bytes":{
+                     "type":"long"
+                  }
+               }
+            },
+            "Upstream":{
+               "properties":{
+                  "ConnectTime":{
+                     "type":"float"
+                  },
+                  "TimeToFirstByte":{
+                     "type":"float"
+                  }
+               }
+            },
             "RemoteIp":{
                "type":"ip"
             },
@@ -81,6 +105,40 @@ var template = `{
                "type":"string",
                "analyzer":"lowercase"
             },
+            "Geoip":{
+               "properties":{
+                  "ContinentName":{
+                     "type":"string",
+                     "index":"not_analyzed"
+                  },
+                  "CountryIsoCode":{
+                     "type":"string",
+                     "index":"not_analyzed"
+                  },
+                  "CountryName":{
+                     "type":"string",
+                     "index":"not_analyzed"
+                  },
+                  "RegionName":{
+                     "type":"string",
+                     "index":"not_analyzed"
+                  },
+                  "CityName":{
+                     "type":"string",
+                     "index":"not_analyzed"
+                  },
+                  "Location":{
+                     "type":"geo_point"
+                  },
+                  "Asn":{
+                     "type":"long"
+                  },
+                  "AsOrganization":{
+                     "type":"string",
+                     "index":"not_analyzed"
+                  }
+               }
+            },
             "HttpVersion":{
                "type":"float"
             },
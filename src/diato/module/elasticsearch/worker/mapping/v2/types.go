@@ -14,7 +14,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package v1
+package v2
 
 import (
 	"time"
@@ -31,30 +31,47 @@ type httpRequest struct {
 	Timestamp time.Time
 	Duration  float64 // In seconds
 
-	//Body_sent struct {
-	//	bytes int
-	//}
+	Body_sent     bodySize
+	Body_received bodySize
+	Upstream      upstreamInfo
+
 	RemoteIp string
 	//Local_ip      string
 	ResponseCode int
 	Method       string
-	/*	Geoip        struct {
-		Region_name    string
-		CountryIsoCode string
-		CityName       string
-		Location       struct {
-			Lat float32
-			Lon float32
-		}
-		ContinentName string
-	}*/
-	HttpVersion float32
-	Referrer    string
-	UserAgent   userAgent
+	Geoip        *geoIp `json:",omitempty"`
+	HttpVersion  float32
+	Referrer     string
+	UserAgent    userAgent
 
 	Diato diatoInfo
 }
 
+type bodySize struct {
+	Bytes int64
+}
+
+type upstreamInfo struct {
+	ConnectTime     float64 // In seconds, 0 if an idle connection was reused
+	TimeToFirstByte float64 // In seconds
+}
+
+type geoIp struct {
+	ContinentName  string
+	CountryIsoCode string
+	CountryName    string
+	RegionName     string
+	CityName       string
+	Location       *geoLocation `json:",omitempty"`
+	Asn            uint32       `json:",omitempty"`
+	AsOrganization string
+}
+
+type geoLocation struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
 type diatoInfo struct {
 	Hostname string
 	// TODO: Version
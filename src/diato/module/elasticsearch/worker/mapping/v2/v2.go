@@ -14,7 +14,7 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-package v1
+package v2
 
 import (
 	"context"
@@ -37,20 +37,20 @@ func NewMapping(client *elastic.Client) *mapping {
 }
 
 func (m *mapping) EnsureTemplate() error {
-	_, err := m.client.IndexPutTemplate("diato-httprequest-v1").
+	_, err := m.client.IndexPutTemplate("diato-httprequest-v2").
 		BodyString(template).
 		Do(context.TODO())
 
 	return err
 }
 
-func (m *mapping) PersistRequest(req *http.Request, resp *http.Response) {
+func (m *mapping) PersistRequest(req *http.Request) {
 	ctx := req.Context()
 	ctxInfo := ctx.Value("diato").(*worker.ContextInfo)
 
-	request := m.getStructuredRequest(req, resp)
+	request := m.getStructuredRequest(req)
 	_, err := m.client.Index().
-		Index(fmt.Sprintf("diato-httprequest-%s-v1", time.Now().Format("20060102"))).
+		Index(fmt.Sprintf("diato-httprequest-%s-v2", time.Now().Format("20060102"))).
 		Type("httprequest").
 		Id(ctxInfo.RequestIdString()).
 		BodyJson(request).
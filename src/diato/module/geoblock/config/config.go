@@ -0,0 +1,63 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"fmt"
+
+	"diato/module/geoblock/rules"
+)
+
+type Config struct {
+	Enabled bool
+	Path    string
+
+	// Sent to denied clients, e.g. 451 for legal obligations
+	DenyStatus int `gcfg:"deny-status"`
+
+	// Deny clients whose country or ASN is unknown when a rule
+	// matches on those, rather than skipping that rule.
+	DenyOnUnknown bool `gcfg:"deny-on-unknown"`
+}
+
+// Check validates the configuration and the rules file. All problems
+// found are returned, rather than just the first one. As the rules
+// may match on country or ASN, it needs to know if GeoIP was enabled.
+func (c *Config) Check(geoIpEnabled bool) []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if c.DenyStatus < 400 || c.DenyStatus > 599 {
+		errs = append(errs, fmt.Errorf("deny-status must be a 4xx or 5xx status, got %d", c.DenyStatus))
+	}
+
+	if c.Path == "" {
+		return append(errs, errors.New("No path was set"))
+	}
+
+	r, err := rules.ParseFile(c.Path)
+	if err != nil {
+		return append(errs, err)
+	}
+	if !geoIpEnabled && rules.RequiresGeoIp(r) {
+		errs = append(errs, errors.New("The rules match on country or ASN, but [geoip] was not enabled"))
+	}
+
+	return errs
+}
@@ -0,0 +1,26 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package geoblock allows or denies requests per host based on the
+// country, ASN or IP address of the client. The server reads the
+// rules from a file, workers fetch them and reload them when the
+// file changes.
+package geoblock
+
+import (
+	_ "diato/module/geoblock/server"
+	_ "diato/module/geoblock/worker"
+)
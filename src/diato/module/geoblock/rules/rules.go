@@ -0,0 +1,174 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rules parses the file that determines which clients may
+// access which hosts. Each line holds a host, an action and one or
+// more matches, e.g.:
+//
+//	# <host> <allow|deny> <match> [<match> ...]
+//	example.com  deny   country:CN country:RU
+//	example.org  allow  country:NL asn:64496 192.0.2.0/24
+//	example.org  deny   all
+//	*            deny   country:KP
+//
+// The rules of a host are evaluated in order, followed by those of
+// '*'. The first rule with a match decides; if none matches, the
+// client is allowed.
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"strings"
+
+	pb "diato/module/geoblock/pb"
+	"diato/util/hostname"
+)
+
+func ParseFile(path string) (*pb.Rules, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read file %s: %s", path, err.Error())
+	}
+
+	rules, err := Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse file %s: %s", path, err.Error())
+	}
+
+	return rules, nil
+}
+
+func Parse(contents []byte) (*pb.Rules, error) {
+	rules := &pb.Rules{
+		Rules: make([]*pb.Rule, 0),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		rule, err := parseRule(fields)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func parseRule(fields []string) (*pb.Rule, error) {
+	if len(fields) < 3 {
+		return nil, errors.New("Expected a host, an action and at least one match")
+	}
+
+	rule := &pb.Rule{
+		Host: hostname.Normalize(fields[0]),
+	}
+
+	switch fields[1] {
+	case "allow":
+		rule.Allow = true
+	case "deny":
+	default:
+		return nil, fmt.Errorf("Unknown action '%s', expected allow or deny", fields[1])
+	}
+
+	for _, match := range fields[2:] {
+		if err := parseMatch(rule, match); err != nil {
+			return nil, err
+		}
+	}
+
+	return rule, nil
+}
+
+func parseMatch(rule *pb.Rule, match string) error {
+	switch {
+	case match == "all":
+		rule.All = true
+
+	case strings.HasPrefix(match, "country:"):
+		country := strings.ToUpper(strings.TrimPrefix(match, "country:"))
+		if len(country) != 2 {
+			return fmt.Errorf("Invalid country '%s', expected an ISO 3166-1 alpha-2 code", match)
+		}
+		rule.Countries = append(rule.Countries, country)
+
+	case strings.HasPrefix(match, "asn:"):
+		asn, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(match, "asn:"), "AS"), 10, 32)
+		if err != nil || asn == 0 {
+			return fmt.Errorf("Invalid ASN '%s'", match)
+		}
+		rule.Asns = append(rule.Asns, uint32(asn))
+
+	default:
+		cidr, err := ParseCidr(match)
+		if err != nil {
+			return fmt.Errorf("Invalid match '%s', expected all, country:, asn:, an IP or a CIDR", match)
+		}
+		rule.Cidrs = append(rule.Cidrs, cidr.String())
+	}
+
+	return nil
+}
+
+// ParseCidr parses a CIDR, or a single IP address as if it was one
+func ParseCidr(s string) (*net.IPNet, error) {
+	if !strings.Contains(s, "/") {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, fmt.Errorf("Invalid IP address '%s'", s)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+
+	_, cidr, err := net.ParseCIDR(s)
+	return cidr, err
+}
+
+// RequiresGeoIp tells if any of the rules match on country or ASN,
+// which can only be done if GeoIP was enabled.
+func RequiresGeoIp(rules *pb.Rules) bool {
+	for _, rule := range rules.Rules {
+		if len(rule.Countries) > 0 || len(rule.Asns) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
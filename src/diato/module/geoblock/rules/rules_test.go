@@ -0,0 +1,107 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	pb "diato/module/geoblock/pb"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []*pb.Rule // nil if parsing should fail
+	}{
+		{
+			name:     "empty",
+			contents: "# Nothing but comments\n\n",
+			want:     []*pb.Rule{},
+		},
+		{
+			name:     "countries",
+			contents: "Example.COM deny country:CN country:ru # Keep out",
+			want:     []*pb.Rule{{Host: "example.com", Countries: []string{"CN", "RU"}}},
+		},
+		{
+			name:     "mixed matches",
+			contents: "example.org allow country:NL asn:64496 asn:AS64497 192.0.2.0/24 2001:db8::1",
+			want: []*pb.Rule{{
+				Host: "example.org", Allow: true,
+				Countries: []string{"NL"},
+				Asns:      []uint32{64496, 64497},
+				Cidrs:     []string{"192.0.2.0/24", "2001:db8::1/128"},
+			}},
+		},
+		{
+			name:     "order is kept",
+			contents: "example.org allow 192.0.2.10\nexample.org deny all\n* deny country:KP",
+			want: []*pb.Rule{
+				{Host: "example.org", Allow: true, Cidrs: []string{"192.0.2.10/32"}},
+				{Host: "example.org", All: true},
+				{Host: "*", Countries: []string{"KP"}},
+			},
+		},
+		{name: "no matches", contents: "example.com deny"},
+		{name: "unknown action", contents: "example.com block all"},
+		{name: "invalid country", contents: "example.com deny country:NLD"},
+		{name: "empty country", contents: "example.com deny country:"},
+		{name: "invalid asn", contents: "example.com deny asn:foo"},
+		{name: "zero asn", contents: "example.com deny asn:0"},
+		{name: "invalid match", contents: "example.com deny continent:EU"},
+	}
+
+	for _, test := range tests {
+		got, err := Parse([]byte(test.contents))
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got rules %v", test.name, got.Rules)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: could not parse: %s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got.Rules, test.want) {
+			t.Errorf("%s: got rules %v, want %v", test.name, got.Rules, test.want)
+		}
+	}
+}
+
+func TestRequiresGeoIp(t *testing.T) {
+	tests := []struct {
+		contents string
+		want     bool
+	}{
+		{"example.com deny 192.0.2.0/24\n* deny all", false},
+		{"example.com deny 192.0.2.0/24\n* deny country:KP", true},
+		{"example.com allow asn:64496", true},
+	}
+
+	for _, test := range tests {
+		rules, err := Parse([]byte(test.contents))
+		if err != nil {
+			t.Fatalf("Could not parse %q: %s", test.contents, err.Error())
+		}
+		if got := RequiresGeoIp(rules); got != test.want {
+			t.Errorf("RequiresGeoIp(%q) = %t, want %t", test.contents, got, test.want)
+		}
+	}
+}
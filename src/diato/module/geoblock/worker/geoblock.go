@@ -0,0 +1,97 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package geoblock
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+
+	"diato/config"
+	"diato/metrics"
+	pb "diato/module/geoblock/pb"
+	"diato/util/rulesync"
+	"diato/worker"
+)
+
+const name = "geoblock"
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled       bool
+	denyStatus    int
+	denyOnUnknown bool
+
+	rules *rulesync.Poller // *ruleSet
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.Geoblock.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	module := &module{
+		enabled:       true,
+		denyStatus:    config.Geoblock.DenyStatus,
+		denyOnUnknown: config.Geoblock.DenyOnUnknown,
+	}
+
+	grpc := pb.NewModuleGeoblockClient(w.GetGrpcClientConn())
+	var err error
+	module.rules, err = rulesync.NewPoller("geoblock rules", func(ctx context.Context, version uint64) (rulesync.Rules, error) {
+		return grpc.GetRules(ctx, &pb.RulesRequest{Version: version})
+	}, func(res rulesync.Rules) (interface{}, int, error) {
+		rules := res.(*pb.Rules)
+		set, err := newRuleSet(rules)
+		return set, len(rules.Rules), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) ProcessRequest(req *http.Request) {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+
+	c := &client{geoIp: ctxInfo.GeoIp()}
+	if ip, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		c.ip = net.ParseIP(ip)
+	}
+
+	allowed, unknown := m.rules.Current().(*ruleSet).allowed(ctxInfo.Host(), c, m.denyOnUnknown)
+	if unknown {
+		metrics.GeoblockUnknownClients.Inc(strconv.FormatBool(allowed))
+	}
+	if !allowed {
+		ctxInfo.Intervene(&worker.Intervention{Status: m.denyStatus})
+	}
+}
@@ -0,0 +1,151 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package geoblock
+
+import (
+	"fmt"
+	"net"
+
+	pb "diato/module/geoblock/pb"
+	"diato/module/geoblock/rules"
+	"diato/pb"
+)
+
+// ruleSet holds the rules as received from the server,
+// in a form that allows for matching them quickly.
+type ruleSet struct {
+	// By host, in the order they're to be evaluated
+	byHost map[string][]*rule
+}
+
+type rule struct {
+	allow bool
+
+	all       bool
+	countries map[string]bool
+	asns      map[uint32]bool
+	nets      []*net.IPNet
+}
+
+type client struct {
+	ip    net.IP
+	geoIp *diato.GeoIpResponse // nil if the lookup failed
+}
+
+// country returns the country of the client, or "" if it's unknown
+func (c *client) country() string {
+	if c.geoIp == nil {
+		return ""
+	}
+	return c.geoIp.CountryIsoCode
+}
+
+// asn returns the ASN of the client, or 0 if it's unknown
+func (c *client) asn() uint32 {
+	if c.geoIp == nil {
+		return 0
+	}
+	return c.geoIp.Asn
+}
+
+func newRuleSet(res *pb.Rules) (*ruleSet, error) {
+	set := &ruleSet{
+		byHost: make(map[string][]*rule),
+	}
+
+	for _, r := range res.Rules {
+		compiled := &rule{
+			allow:     r.Allow,
+			all:       r.All,
+			countries: make(map[string]bool),
+			asns:      make(map[uint32]bool),
+			nets:      make([]*net.IPNet, 0, len(r.Cidrs)),
+		}
+
+		for _, country := range r.Countries {
+			compiled.countries[country] = true
+		}
+		for _, asn := range r.Asns {
+			compiled.asns[asn] = true
+		}
+		for _, cidr := range r.Cidrs {
+			ipNet, err := rules.ParseCidr(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("Could not parse rule for host '%s': %s", r.Host, err.Error())
+			}
+			compiled.nets = append(compiled.nets, ipNet)
+		}
+
+		set.byHost[r.Host] = append(set.byHost[r.Host], compiled)
+	}
+
+	return set, nil
+}
+
+// allowed tells if the client may access the host. The rules of the
+// host are evaluated first, followed by those that apply to all hosts.
+// Rules on country or ASN can't tell if a client whose location is
+// unknown matches. Those are skipped, or deny the client right away if
+// denyOnUnknown is set. unknown tells if such a rule was run into.
+func (s *ruleSet) allowed(host string, c *client, denyOnUnknown bool) (allowed, unknown bool) {
+	for _, h := range []string{host, "*"} {
+		for _, r := range s.byHost[h] {
+			if r.matches(c) {
+				return r.allow, unknown
+			}
+
+			if r.cannotLocate(c) {
+				if denyOnUnknown {
+					return false, true
+				}
+				unknown = true
+			}
+		}
+	}
+
+	return true, unknown
+}
+
+// cannotLocate tells if the rule matches on a country or ASN that isn't
+// known of the client. The GeoIP databases don't have every address, in
+// which case their country is empty and their ASN is 0.
+func (r *rule) cannotLocate(c *client) bool {
+	return (len(r.countries) > 0 && c.country() == "") ||
+		(len(r.asns) > 0 && c.asn() == 0)
+}
+
+func (r *rule) matches(c *client) bool {
+	if r.all {
+		return true
+	}
+
+	if country := c.country(); country != "" && r.countries[country] {
+		return true
+	}
+	if asn := c.asn(); asn != 0 && r.asns[asn] {
+		return true
+	}
+
+	if c.ip != nil {
+		for _, ipNet := range r.nets {
+			if ipNet.Contains(c.ip) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
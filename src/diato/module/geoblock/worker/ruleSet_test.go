@@ -0,0 +1,79 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package geoblock
+
+import (
+	"net"
+	"testing"
+
+	"diato/module/geoblock/rules"
+	"diato/pb"
+)
+
+func TestRuleSetAllowed(t *testing.T) {
+	parsed, err := rules.Parse([]byte(`
+example.com  allow  192.0.2.10
+example.com  deny   country:CN
+example.org  allow  asn:64496
+example.org  deny   all
+*            deny   country:KP
+`))
+	if err != nil {
+		t.Fatalf("Could not parse rules: %s", err.Error())
+	}
+	set, err := newRuleSet(parsed)
+	if err != nil {
+		t.Fatalf("Could not build rule set: %s", err.Error())
+	}
+
+	// Lookups of addresses the databases don't know return empty fields
+	notFound := &diato.GeoIpResponse{}
+
+	tests := []struct {
+		name          string
+		host          string
+		ip            string
+		geoIp         *diato.GeoIpResponse
+		denyOnUnknown bool
+		wantAllowed   bool
+		wantUnknown   bool
+	}{
+		{"allowed by cidr", "example.com", "192.0.2.10", &diato.GeoIpResponse{CountryIsoCode: "CN"}, false, true, false},
+		{"denied by country", "example.com", "192.0.2.11", &diato.GeoIpResponse{CountryIsoCode: "CN"}, false, false, false},
+		{"other country", "example.com", "192.0.2.11", &diato.GeoIpResponse{CountryIsoCode: "NL"}, false, true, false},
+		{"rules of all hosts", "example.net", "192.0.2.11", &diato.GeoIpResponse{CountryIsoCode: "KP"}, false, false, false},
+		{"allowed by asn", "example.org", "192.0.2.11", &diato.GeoIpResponse{Asn: 64496}, false, true, false},
+		{"denied by all", "example.org", "192.0.2.11", &diato.GeoIpResponse{Asn: 64497}, false, false, false},
+
+		{"lookup failed", "example.com", "192.0.2.11", nil, false, true, true},
+		{"lookup failed, deny", "example.com", "192.0.2.11", nil, true, false, true},
+		{"not found", "example.com", "192.0.2.11", notFound, false, true, true},
+		{"not found, deny", "example.com", "192.0.2.11", notFound, true, false, true},
+		{"not found, cidr first", "example.com", "192.0.2.10", notFound, true, true, false},
+		{"asn not found", "example.org", "192.0.2.11", &diato.GeoIpResponse{CountryIsoCode: "NL"}, true, false, true},
+		{"country not found", "example.net", "192.0.2.11", &diato.GeoIpResponse{Asn: 64496}, false, true, true},
+		{"country known, asn not", "example.net", "192.0.2.11", &diato.GeoIpResponse{CountryIsoCode: "NL"}, true, true, false},
+	}
+
+	for _, test := range tests {
+		c := &client{ip: net.ParseIP(test.ip), geoIp: test.geoIp}
+		allowed, unknown := set.allowed(test.host, c, test.denyOnUnknown)
+		if allowed != test.wantAllowed || unknown != test.wantUnknown {
+			t.Errorf("%s: got allowed %t and unknown %t, want %t and %t",
+				test.name, allowed, unknown, test.wantAllowed, test.wantUnknown)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package rules parses the file that determines which headers of
+// requests and responses are modified. Each line holds a scope, whether
+// it applies to the request or the response, an operation, a header and
+// for most operations a value, e.g.:
+//
+//	# <scope> <request|response> <set|add|remove|rename> <header> [<value>]
+//	*                 response  remove  X-Powered-By
+//	listen:https-443  response  set     Strict-Transport-Security  max-age=31536000
+//	example.com       response  set     X-Robots-Tag               noindex, nofollow
+//	example.com       request   set     X-Request-Id               ${request_id}
+//	example.com       request   rename  X-Forwarded-Host           X-Original-Host
+//
+// The scope is either '*' for all requests, 'listen:<name>' for those
+// that came in through a listen section, or a host. Rules are applied
+// in that order, so the ones of a host go last and win. Within a scope
+// they're applied in the order they appear in.
+//
+// Values span the rest of the line and may hold the variables
+// ${client_ip}, ${request_id}, ${host} and ${tls}. Only lines that
+// start with a '#' are comments, as values may contain them.
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	pb "diato/module/headers/pb"
+	"diato/util/hostname"
+)
+
+const listenScopePrefix = "listen:"
+
+// The variables that can be used in values
+var Variables = []string{"client_ip", "request_id", "host", "tls"}
+
+// Headers that are managed by us or by net/http, changing them
+// would either have no effect or break the connection.
+var protectedHeaders = map[string]bool{
+	"Connection":        true,
+	"Content-Length":    true,
+	"Host":              true,
+	"Keep-Alive":        true,
+	"Te":                true,
+	"Trailer":           true,
+	"Transfer-Encoding": true,
+	"Upgrade":           true,
+}
+
+func ParseFile(path string) (*pb.Rules, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read file %s: %s", path, err.Error())
+	}
+
+	rules, err := Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse file %s: %s", path, err.Error())
+	}
+
+	return rules, nil
+}
+
+func Parse(contents []byte) (*pb.Rules, error) {
+	rules := &pb.Rules{
+		Rules: make([]*pb.Rule, 0),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parseRule(line)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+// ListenScope returns the name of the listen section
+// if the scope is one, e.g. 'listen:https-443'.
+func ListenScope(scope string) (string, bool) {
+	if !strings.HasPrefix(scope, listenScopePrefix) {
+		return "", false
+	}
+
+	return scope[len(listenScopePrefix):], true
+}
+
+// ScopeOfListen returns the scope of the rules
+// that apply to the given listen section.
+func ScopeOfListen(listen string) string {
+	return listenScopePrefix + listen
+}
+
+func parseRule(line string) (*pb.Rule, error) {
+	fields, value := splitFields(line, 4)
+	if len(fields) < 4 {
+		return nil, errors.New("Expected a scope, request or response, an operation and a header")
+	}
+
+	rule := &pb.Rule{
+		Scope:     fields[0],
+		Operation: fields[2],
+		Header:    http.CanonicalHeaderKey(fields[3]),
+		Value:     value,
+	}
+
+	if listen, ok := ListenScope(rule.Scope); ok {
+		if listen == "" {
+			return nil, errors.New("No listen section was given")
+		}
+	} else {
+		rule.Scope = hostname.Normalize(rule.Scope)
+	}
+
+	switch fields[1] {
+	case "request":
+	case "response":
+		rule.Response = true
+	default:
+		return nil, fmt.Errorf("Expected request or response, got '%s'", fields[1])
+	}
+
+	if err := checkHeader(rule.Header); err != nil {
+		return nil, err
+	}
+
+	switch rule.Operation {
+	case "set", "add":
+		if rule.Value == "" {
+			return nil, fmt.Errorf("No value was given to %s", rule.Operation)
+		}
+		if err := checkVariables(rule.Value); err != nil {
+			return nil, err
+		}
+	case "remove":
+		if rule.Value != "" {
+			return nil, fmt.Errorf("Unexpected value '%s', remove does not take one", rule.Value)
+		}
+	case "rename":
+		if rule.Value == "" || strings.ContainsAny(rule.Value, " \t") {
+			return nil, errors.New("Expected the new name of the header to rename to")
+		}
+		rule.Value = http.CanonicalHeaderKey(rule.Value)
+		if err := checkHeader(rule.Value); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unknown operation '%s', expected set, add, remove or rename", rule.Operation)
+	}
+
+	return rule, nil
+}
+
+// splitFields returns the first n whitespace separated fields
+// of the line, and whatever remains of it after those.
+func splitFields(line string, n int) ([]string, string) {
+	fields := make([]string, 0, n)
+	for len(fields) < n {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			break
+		}
+
+		end := strings.IndexAny(line, " \t")
+		if end == -1 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+
+	return fields, strings.TrimSpace(line)
+}
+
+func checkHeader(header string) error {
+	if protectedHeaders[header] {
+		return fmt.Errorf("Header %s can not be modified", header)
+	}
+
+	for _, c := range header {
+		if c <= ' ' || c >= 0x7f || strings.ContainsRune("\"(),/:;<=>?@[\\]{}", c) {
+			return fmt.Errorf("Invalid header name '%s'", header)
+		}
+	}
+
+	return nil
+}
+
+// checkVariables makes sure all variables in the value are known
+func checkVariables(value string) error {
+	for {
+		start := strings.Index(value, "${")
+		if start == -1 {
+			return nil
+		}
+
+		end := strings.Index(value[start:], "}")
+		if end == -1 {
+			return fmt.Errorf("Unterminated variable in '%s'", value)
+		}
+
+		name := value[start+2 : start+end]
+		known := false
+		for _, v := range Variables {
+			known = known || v == name
+		}
+		if !known {
+			return fmt.Errorf("Unknown variable '${%s}', expected one of ${%s}",
+				name, strings.Join(Variables, "}, ${"))
+		}
+
+		value = value[start+end+1:]
+	}
+}
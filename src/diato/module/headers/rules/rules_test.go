@@ -0,0 +1,118 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	pb "diato/module/headers/pb"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []*pb.Rule // nil if parsing should fail
+	}{
+		{
+			name:     "empty",
+			contents: "  # Nothing but comments\n\n",
+			want:     []*pb.Rule{},
+		},
+		{
+			name:     "remove",
+			contents: "* response remove x-powered-by",
+			want:     []*pb.Rule{{Scope: "*", Response: true, Operation: "remove", Header: "X-Powered-By"}},
+		},
+		{
+			name:     "value spans the rest of the line",
+			contents: "Example.COM.  response  set  X-Robots-Tag   noindex, nofollow # not a comment  ",
+			want: []*pb.Rule{{
+				Scope: "example.com", Response: true, Operation: "set",
+				Header: "X-Robots-Tag", Value: "noindex, nofollow # not a comment",
+			}},
+		},
+		{
+			name:     "listen scope and variables",
+			contents: "listen:https-443\trequest\tadd\tX-Client\t${client_ip} via ${tls}",
+			want: []*pb.Rule{{
+				Scope: "listen:https-443", Operation: "add", Header: "X-Client", Value: "${client_ip} via ${tls}",
+			}},
+		},
+		{
+			name:     "rename",
+			contents: "example.com request rename x-forwarded-host x-original-host",
+			want: []*pb.Rule{{
+				Scope: "example.com", Operation: "rename", Header: "X-Forwarded-Host", Value: "X-Original-Host",
+			}},
+		},
+		{name: "too few fields", contents: "* response remove"},
+		{name: "no listen section", contents: "listen: response remove X-Powered-By"},
+		{name: "neither request nor response", contents: "* both remove X-Powered-By"},
+		{name: "unknown operation", contents: "* response append X-Foo bar"},
+		{name: "set without value", contents: "* response set X-Foo"},
+		{name: "remove with value", contents: "* response remove X-Foo bar"},
+		{name: "rename to nothing", contents: "* request rename X-Foo"},
+		{name: "rename to several", contents: "* request rename X-Foo X-Bar X-Baz"},
+		{name: "rename to protected", contents: "* request rename X-Foo Host"},
+		{name: "protected header", contents: "* request set content-length 0"},
+		{name: "invalid header", contents: "* request set X-Foo: bar"},
+		{name: "unknown variable", contents: "* request set X-Foo ${client_port}"},
+		{name: "unterminated variable", contents: "* request set X-Foo ${client_ip"},
+	}
+
+	for _, test := range tests {
+		got, err := Parse([]byte(test.contents))
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got rules %v", test.name, got.Rules)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: could not parse: %s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got.Rules, test.want) {
+			t.Errorf("%s: got rules %v, want %v", test.name, got.Rules, test.want)
+		}
+	}
+}
+
+func TestListenScope(t *testing.T) {
+	tests := []struct {
+		scope      string
+		wantListen string
+		wantOk     bool
+	}{
+		{"listen:https-443", "https-443", true},
+		{"listen:", "", true},
+		{"example.com", "", false},
+		{"*", "", false},
+	}
+
+	for _, test := range tests {
+		listen, ok := ListenScope(test.scope)
+		if listen != test.wantListen || ok != test.wantOk {
+			t.Errorf("ListenScope(%q) = %q, %t, want %q, %t", test.scope, listen, ok, test.wantListen, test.wantOk)
+		}
+		if ok && ScopeOfListen(listen) != test.scope {
+			t.Errorf("ScopeOfListen(%q) = %q, want %q", listen, ScopeOfListen(listen), test.scope)
+		}
+	}
+}
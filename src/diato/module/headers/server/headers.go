@@ -0,0 +1,68 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package headers
+
+import (
+	"diato/config"
+	"diato/module/headers/rules"
+	"diato/server"
+	"diato/util/rulesync"
+)
+
+const name = "headers"
+
+func init() {
+	server.RegisterModule(newModule)
+}
+
+type module struct {
+	enabled bool
+
+	rules *rulesync.File // []*pb.Rule
+}
+
+func newModule(s *server.Server, config *config.Config) ([]server.Module, error) {
+	module := &module{
+		enabled: config.Headers.Enabled,
+	}
+
+	if !module.Enabled() {
+		return []server.Module{module}, nil
+	}
+
+	var err error
+	module.rules, err = rulesync.NewFile("header rules", config.Headers.Path, func(path string) (interface{}, int, error) {
+		r, err := rules.ParseFile(path)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		return r.Rules, len(r.Rules), nil
+	})
+	if err != nil {
+		return []server.Module{}, err
+	}
+
+	return []server.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
@@ -0,0 +1,80 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package headers
+
+import (
+	"context"
+	"net/http"
+
+	"diato/config"
+	pb "diato/module/headers/pb"
+	"diato/util/rulesync"
+	"diato/worker"
+)
+
+const name = "headers"
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled bool
+
+	rules *rulesync.Poller // *ruleSet
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.Headers.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	module := &module{
+		enabled: true,
+	}
+
+	grpc := pb.NewModuleHeadersClient(w.GetGrpcClientConn())
+	var err error
+	module.rules, err = rulesync.NewPoller("header rules", func(ctx context.Context, version uint64) (rulesync.Rules, error) {
+		return grpc.GetRules(ctx, &pb.RulesRequest{Version: version})
+	}, func(res rulesync.Rules) (interface{}, int, error) {
+		rules := res.(*pb.Rules)
+		return newRuleSet(rules), len(rules.Rules), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) ModifyRequestHeader(req *http.Request) {
+	m.rules.Current().(*ruleSet).apply(req, req.Header, false)
+}
+
+func (m *module) ModifyResponseHeader(res *http.Response) {
+	m.rules.Current().(*ruleSet).apply(res.Request, res.Header, true)
+}
@@ -0,0 +1,172 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package headers
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	pb "diato/module/headers/pb"
+	"diato/module/headers/rules"
+	"diato/worker"
+)
+
+// ruleSet holds the rules as received from the server, by scope
+// and in the order they're to be applied.
+type ruleSet struct {
+	request  map[string][]*rule
+	response map[string][]*rule
+}
+
+type rule struct {
+	operation string
+	header    string
+
+	// The new name when renaming, the value otherwise
+	newName string
+	value   []valuePart
+}
+
+// A value consists of literal text and variables, in turn
+type valuePart struct {
+	literal  string
+	variable string
+}
+
+func newRuleSet(res *pb.Rules) *ruleSet {
+	set := &ruleSet{
+		request:  make(map[string][]*rule),
+		response: make(map[string][]*rule),
+	}
+
+	for _, r := range res.Rules {
+		compiled := &rule{
+			operation: r.Operation,
+			header:    r.Header,
+		}
+
+		if r.Operation == "rename" {
+			compiled.newName = r.Value
+		} else {
+			compiled.value = parseValue(r.Value)
+		}
+
+		if r.Response {
+			set.response[r.Scope] = append(set.response[r.Scope], compiled)
+		} else {
+			set.request[r.Scope] = append(set.request[r.Scope], compiled)
+		}
+	}
+
+	return set
+}
+
+// parseValue splits the value into literal text and ${variables}.
+// The rules were validated by the server, so the variables are known.
+func parseValue(value string) []valuePart {
+	parts := make([]valuePart, 0)
+	for value != "" {
+		start := strings.Index(value, "${")
+		if start == -1 {
+			parts = append(parts, valuePart{literal: value})
+			break
+		}
+		end := start + strings.Index(value[start:], "}")
+
+		if start > 0 {
+			parts = append(parts, valuePart{literal: value[:start]})
+		}
+		parts = append(parts, valuePart{variable: value[start+2 : end]})
+		value = value[end+1:]
+	}
+
+	return parts
+}
+
+// apply modifies the headers of the request, or of its response. The
+// rules for all requests go first, then those of the listen section
+// and finally those of the host, so the most specific ones win.
+func (s *ruleSet) apply(req *http.Request, header http.Header, response bool) {
+	byScope := s.request
+	if response {
+		byScope = s.response
+	}
+	if len(byScope) == 0 {
+		return
+	}
+
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+	scopes := []string{"*", "", ctxInfo.Host()}
+	if listen := ctxInfo.Listen(); listen != "" {
+		scopes[1] = rules.ScopeOfListen(listen)
+	}
+
+	for _, scope := range scopes {
+		for _, r := range byScope[scope] {
+			r.apply(req, ctxInfo, header)
+		}
+	}
+}
+
+func (r *rule) apply(req *http.Request, ctxInfo *worker.ContextInfo, header http.Header) {
+	switch r.operation {
+	case "set":
+		header.Set(r.header, r.interpolate(req, ctxInfo))
+	case "add":
+		header.Add(r.header, r.interpolate(req, ctxInfo))
+	case "remove":
+		header.Del(r.header)
+	case "rename":
+		if values, ok := header[r.header]; ok {
+			header.Del(r.header)
+			header[r.newName] = values
+		}
+	}
+}
+
+func (r *rule) interpolate(req *http.Request, ctxInfo *worker.ContextInfo) string {
+	if len(r.value) == 1 && r.value[0].variable == "" {
+		return r.value[0].literal
+	}
+
+	res := ""
+	for _, part := range r.value {
+		switch part.variable {
+		case "":
+			res += part.literal
+		case "client_ip":
+			res += clientIp(req.RemoteAddr)
+		case "request_id":
+			res += ctxInfo.RequestIdString()
+		case "host":
+			res += ctxInfo.Host()
+		case "tls":
+			res += strconv.FormatBool(ctxInfo.Tls())
+		}
+	}
+
+	return res
+}
+
+func clientIp(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return host
+	}
+
+	return hostport
+}
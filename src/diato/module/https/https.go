@@ -0,0 +1,26 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package https redirects plain HTTP requests to HTTPS and adds
+// Strict-Transport-Security headers, per host. Requests are only
+// redirected if a certificate for the host is present. The server
+// reads the policies from a file, workers ask for those of a host.
+package https
+
+import (
+	_ "diato/module/https/server"
+	_ "diato/module/https/worker"
+)
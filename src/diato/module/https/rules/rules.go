@@ -0,0 +1,126 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rules parses the file that holds the HTTPS policy of each
+// host. Each line holds a host followed by the options that apply to
+// it, e.g.:
+//
+//	# <host> [redirect] [hsts=<max-age>] [include-subdomains] [preload]
+//	*                redirect
+//	example.com      redirect  hsts=31536000  include-subdomains  preload
+//	legacy.example.com
+//
+// The policy of a host replaces that of '*', options are not merged.
+// Redirect sends plain HTTP requests to HTTPS, provided a certificate
+// for the host is present. Hsts sets the max-age in seconds of the
+// Strict-Transport-Security header sent over HTTPS. Preloading
+// requires a max-age of at least a year and include-subdomains.
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	pb "diato/module/https/pb"
+	"diato/util/hostname"
+)
+
+// The minimum max-age for a host to be accepted in preload lists
+const preloadMinMaxAge = 31536000
+
+func ParseFile(path string) (map[string]*pb.Policy, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read file %s: %s", path, err.Error())
+	}
+
+	policies, err := Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse file %s: %s", path, err.Error())
+	}
+
+	return policies, nil
+}
+
+func Parse(contents []byte) (map[string]*pb.Policy, error) {
+	policies := make(map[string]*pb.Policy)
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i != -1 {
+			line = line[:i]
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		host := hostname.Normalize(fields[0])
+		if _, ok := policies[host]; ok {
+			return nil, fmt.Errorf("Line %d: Host '%s' was already listed", lineNo, host)
+		}
+
+		policy, err := parsePolicy(fields[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		policies[host] = policy
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func parsePolicy(options []string) (*pb.Policy, error) {
+	policy := &pb.Policy{}
+	for _, option := range options {
+		switch {
+		case option == "redirect":
+			policy.Redirect = true
+		case option == "include-subdomains":
+			policy.HstsIncludeSubdomains = true
+		case option == "preload":
+			policy.HstsPreload = true
+		case strings.HasPrefix(option, "hsts="):
+			maxAge, err := strconv.ParseUint(option[len("hsts="):], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid max-age '%s'", option[len("hsts="):])
+			}
+			policy.HstsMaxAge = uint32(maxAge)
+		default:
+			return nil, fmt.Errorf("Unknown option '%s'", option)
+		}
+	}
+
+	if policy.HstsMaxAge == 0 && (policy.HstsIncludeSubdomains || policy.HstsPreload) {
+		return nil, errors.New("include-subdomains and preload require hsts to be set")
+	}
+	if policy.HstsPreload && (policy.HstsMaxAge < preloadMinMaxAge || !policy.HstsIncludeSubdomains) {
+		return nil, fmt.Errorf("preload requires hsts to be at least %d and include-subdomains", preloadMinMaxAge)
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,80 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	pb "diato/module/https/pb"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     map[string]*pb.Policy // nil if parsing should fail
+	}{
+		{
+			name:     "empty",
+			contents: "# Nothing but comments\n\n",
+			want:     map[string]*pb.Policy{},
+		},
+		{
+			name: "policies",
+			contents: `
+*                  redirect
+Example.COM.       redirect  hsts=31536000  include-subdomains  preload # For all
+legacy.example.com
+hsts.example.com   hsts=300
+`,
+			want: map[string]*pb.Policy{
+				"*": {Redirect: true},
+				"example.com": {
+					Redirect: true, HstsMaxAge: 31536000, HstsIncludeSubdomains: true, HstsPreload: true,
+				},
+				"legacy.example.com": {},
+				"hsts.example.com":   {HstsMaxAge: 300},
+			},
+		},
+		{name: "listed twice", contents: "example.com redirect\nEXAMPLE.com hsts=300"},
+		{name: "unknown option", contents: "example.com redirect=yes"},
+		{name: "invalid max-age", contents: "example.com hsts=1y"},
+		{name: "negative max-age", contents: "example.com hsts=-1"},
+		{name: "include-subdomains without hsts", contents: "example.com include-subdomains"},
+		{name: "preload without hsts", contents: "example.com preload"},
+		{name: "preload with short max-age", contents: "example.com hsts=300 include-subdomains preload"},
+		{name: "preload without include-subdomains", contents: "example.com hsts=31536000 preload"},
+	}
+
+	for _, test := range tests {
+		got, err := Parse([]byte(test.contents))
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got policies %v", test.name, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: could not parse: %s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got policies %v, want %v", test.name, got, test.want)
+		}
+	}
+}
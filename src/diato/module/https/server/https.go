@@ -0,0 +1,88 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package https
+
+import (
+	"diato/config"
+	pb "diato/module/https/pb"
+	"diato/module/https/rules"
+	"diato/server"
+	"diato/util/hostname"
+	"diato/util/rulesync"
+)
+
+const name = "https"
+
+func init() {
+	server.RegisterModule(newModule)
+}
+
+type module struct {
+	enabled bool
+
+	server   *server.Server
+	policies *rulesync.File // map[string]*pb.Policy
+}
+
+func newModule(s *server.Server, config *config.Config) ([]server.Module, error) {
+	module := &module{
+		enabled: config.Https.Enabled,
+		server:  s,
+	}
+
+	if !module.Enabled() {
+		return []server.Module{module}, nil
+	}
+
+	var err error
+	module.policies, err = rulesync.NewFile("https policies", config.Https.Path, func(path string) (interface{}, int, error) {
+		policies, err := rules.ParseFile(path)
+		return policies, len(policies), err
+	})
+	if err != nil {
+		return []server.Module{}, err
+	}
+
+	return []server.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+// getPolicy returns the policy of the given host, only redirecting
+// if clients can actually connect to it over TLS.
+func (m *module) getPolicy(host string) *pb.Policy {
+	host = hostname.Normalize(host)
+
+	policies := m.policies.Rules().(map[string]*pb.Policy)
+	policy, ok := policies[host]
+	if !ok {
+		policy, ok = policies["*"]
+	}
+
+	if !ok {
+		return &pb.Policy{}
+	}
+
+	res := *policy
+	res.Redirect = res.Redirect && m.server.HasCertificate(host)
+	return &res
+}
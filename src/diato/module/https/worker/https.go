@@ -0,0 +1,174 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package https
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"diato/config"
+	pb "diato/module/https/pb"
+	"diato/worker"
+)
+
+const name = "https"
+
+// Policies are cached, as most clients make more than one request.
+// Not for too long though, a certificate may have been added since.
+const (
+	policyCacheTtl  = 1 * time.Minute
+	policyCacheSize = 65536
+)
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled      bool
+	redirectPort int
+
+	grpc pb.ModuleHttpsClient
+
+	sync.Mutex
+	cache map[string]policyCacheEntry
+}
+
+type policyCacheEntry struct {
+	policy  *pb.Policy
+	expires time.Time
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.Https.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	module := &module{
+		enabled:      true,
+		redirectPort: config.Https.RedirectPort,
+		grpc:         pb.NewModuleHttpsClient(w.GetGrpcClientConn()),
+		cache:        make(map[string]policyCacheEntry),
+	}
+	if module.redirectPort == 0 {
+		module.redirectPort = 443
+	}
+
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+// RewriteRequest redirects plain HTTP requests to HTTPS, if the
+// policy of the host says so.
+func (m *module) RewriteRequest(req *http.Request) {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+	if ctxInfo.Tls() {
+		return
+	}
+
+	host := ctxInfo.Host()
+	if policy := m.getPolicy(req.Context(), host); !policy.Redirect {
+		return
+	}
+
+	if m.redirectPort != 443 {
+		host = net.JoinHostPort(host, fmt.Sprintf("%d", m.redirectPort))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]" // IPv6
+	}
+
+	// Anything but GET and HEAD could lose its body when
+	// redirected with a 301, a 308 prevents that.
+	status := http.StatusMovedPermanently
+	if req.Method != "GET" && req.Method != "HEAD" {
+		status = http.StatusPermanentRedirect
+	}
+
+	ctxInfo.Intervene(&worker.Intervention{
+		Status: status,
+		Header: http.Header{"Location": []string{"https://" + host + req.URL.RequestURI()}},
+	})
+}
+
+func (m *module) ModifyRequestHeader(req *http.Request) {
+}
+
+// ModifyResponseHeader adds the Strict-Transport-Security header to
+// responses sent over HTTPS. Browsers ignore it over plain HTTP.
+func (m *module) ModifyResponseHeader(res *http.Response) {
+	ctxInfo := res.Request.Context().Value("diato").(*worker.ContextInfo)
+	if !ctxInfo.Tls() {
+		return
+	}
+
+	policy := m.getPolicy(res.Request.Context(), ctxInfo.Host())
+	if policy.HstsMaxAge == 0 {
+		return
+	}
+
+	value := fmt.Sprintf("max-age=%d", policy.HstsMaxAge)
+	if policy.HstsIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if policy.HstsPreload {
+		value += "; preload"
+	}
+	res.Header.Set("Strict-Transport-Security", value)
+}
+
+// getPolicy returns the policy of the host. If the server could not
+// be reached, nothing is enforced rather than failing the request.
+func (m *module) getPolicy(ctx context.Context, host string) *pb.Policy {
+	m.Lock()
+	entry, ok := m.cache[host]
+	m.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.policy
+	}
+
+	policy, err := m.grpc.GetPolicy(ctx, &pb.PolicyRequest{Host: host})
+	if err != nil {
+		log.Printf("Could not look up https policy of '%s': %s", host, err.Error())
+		return &pb.Policy{}
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	// Simply starting over is cheaper than keeping track of
+	// which entry was used least recently.
+	if len(m.cache) >= policyCacheSize {
+		m.cache = make(map[string]policyCacheEntry)
+	}
+	m.cache[host] = policyCacheEntry{policy, time.Now().Add(policyCacheTtl)}
+
+	return policy
+}
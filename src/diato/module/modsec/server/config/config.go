@@ -15,7 +15,40 @@
 // limitations under the License.
 package config
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/mattn/go-zglob"
+)
+
 type Config struct {
 	Enabled   bool
 	RulesFile []string `gcfg:"rules-file"`
 }
+
+// Check validates the configuration without loading any rules. All
+// problems found are returned, rather than just the first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if len(c.RulesFile) == 0 {
+		errs = append(errs, errors.New("No rules-file was set"))
+	}
+
+	for _, globPath := range c.RulesFile {
+		paths, err := zglob.Glob(globPath)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Could not resolve rules-file '%s': %s", globPath, err.Error()))
+			continue
+		}
+		if len(paths) == 0 {
+			errs = append(errs, fmt.Errorf("No rule files found for rules-file '%s'", globPath))
+		}
+	}
+
+	return errs
+}
@@ -24,6 +24,7 @@ import (
 	"net/http"
 
 	"diato/config"
+	"diato/metrics"
 	"diato/module/modsec/pb"
 	"diato/worker"
 
@@ -135,6 +136,7 @@ func (m *module) ProcessRequest(req *http.Request) {
 	}
 
 	if txn.ShouldIntervene() {
+		metrics.ModsecInterventions.Inc()
 		log.Printf("Should intervene in request from %s for %s\n",
 			req.RemoteAddr, req.URL,
 		)
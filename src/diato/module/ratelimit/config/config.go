@@ -0,0 +1,93 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Limit allows for a number of requests per period for each distinct
+// key, e.g. 100 requests per minute per client IP.
+type Limit struct {
+	// One of 'ip', 'host', 'path' or 'header:<name>'
+	Key string
+
+	// Optional, the limit only applies to matching requests
+	Host       string
+	PathPrefix string `gcfg:"path-prefix"`
+
+	Requests int
+	Period   string // E.g. '1s' or '1m'
+
+	// The number of requests that may be made at once,
+	// defaults to the number of requests per period.
+	Burst int
+}
+
+// Check validates the limit. All problems found are
+// returned, rather than just the first one.
+func (l *Limit) Check() []error {
+	errs := make([]error, 0)
+
+	switch {
+	case l.Key == "ip", l.Key == "host", l.Key == "path":
+	case strings.HasPrefix(l.Key, "header:"):
+		if http.CanonicalHeaderKey(strings.TrimPrefix(l.Key, "header:")) == "" {
+			errs = append(errs, errors.New("No header name was set in key"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("Unknown key '%s', expected one of ip, host, path or header:<name>", l.Key))
+	}
+
+	if l.PathPrefix != "" && !strings.HasPrefix(l.PathPrefix, "/") {
+		errs = append(errs, fmt.Errorf("path-prefix '%s' must start with a '/'", l.PathPrefix))
+	}
+
+	if l.Requests < 1 {
+		errs = append(errs, fmt.Errorf("requests must be at least 1, got %d", l.Requests))
+	}
+
+	if period, err := time.ParseDuration(l.Period); err != nil {
+		errs = append(errs, fmt.Errorf("Invalid period '%s': %s", l.Period, err.Error()))
+	} else if period <= 0 {
+		errs = append(errs, fmt.Errorf("period must be positive, got '%s'", l.Period))
+	}
+
+	if l.Burst < 0 {
+		errs = append(errs, fmt.Errorf("burst must not be negative, got %d", l.Burst))
+	}
+
+	return errs
+}
+
+// Rate returns the number of requests allowed per second.
+// Only to be used on limits that passed Check().
+func (l *Limit) Rate() float64 {
+	period, _ := time.ParseDuration(l.Period)
+	return float64(l.Requests) / period.Seconds()
+}
+
+func (l *Limit) BurstSize() int {
+	if l.Burst == 0 {
+		return l.Requests
+	}
+
+	return l.Burst
+}
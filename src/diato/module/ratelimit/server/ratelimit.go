@@ -0,0 +1,93 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	"diato/config"
+	"diato/metrics"
+	pb "diato/module/ratelimit/pb"
+	"diato/server"
+)
+
+const name = "ratelimit"
+
+func init() {
+	server.RegisterModule(newModule)
+}
+
+type module struct {
+	enabled bool
+
+	limits map[string]*store
+}
+
+func newModule(s *server.Server, config *config.Config) ([]server.Module, error) {
+	module := &module{
+		enabled: len(config.Ratelimit) > 0,
+		limits:  make(map[string]*store),
+	}
+
+	if !module.Enabled() {
+		return []server.Module{module}, nil
+	}
+
+	for name, l := range config.Ratelimit {
+		if errs := l.Check(); len(errs) > 0 {
+			return []server.Module{}, fmt.Errorf("Invalid rate limit '%s': %s", name, errs[0].Error())
+		}
+
+		module.limits[name] = newStore(l.Rate(), l.BurstSize())
+	}
+
+	return []server.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) take(buckets []*pb.Bucket) (*pb.TakeResponse, error) {
+	res := &pb.TakeResponse{Allowed: true}
+	now := time.Now()
+
+	for _, b := range buckets {
+		s, ok := m.limits[b.Limit]
+		if !ok {
+			return nil, fmt.Errorf("Unknown rate limit '%s'", b.Limit)
+		}
+
+		allowed, retryAfter := s.take(b.Key, now)
+		if allowed {
+			continue
+		}
+
+		metrics.RateLimited.Inc(b.Limit)
+		if res.Allowed || retryAfter > res.RetryAfter {
+			res.Allowed = false
+			res.RetryAfter = retryAfter
+			res.Limit = b.Limit
+		}
+	}
+
+	return res, nil
+}
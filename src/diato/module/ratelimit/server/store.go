@@ -0,0 +1,157 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ratelimit
+
+import (
+	"container/list"
+	"hash/fnv"
+	"math"
+	"sync"
+	"time"
+
+	"diato/util/stop"
+)
+
+const (
+	// Spreads the buckets over this many locks
+	storeShards = 32
+
+	// Limits the memory used by a limit when a huge number of clients
+	// shows up. Beyond this number the buckets used least recently are
+	// dropped, so clients that keep on making requests remain limited.
+	maxBuckets = 100000
+
+	// How often buckets that filled up again are removed
+	cleanupInterval = time.Minute
+)
+
+// store keeps a token bucket for each key of a limit. Each
+// limit has a store of its own, so they can't crowd each other out.
+type store struct {
+	rate  float64
+	burst int
+
+	// Time it takes for an empty bucket to fill up
+	fillTime time.Duration
+
+	shards [storeShards]*storeShard
+}
+
+type storeShard struct {
+	sync.Mutex
+	buckets map[string]*list.Element
+	lru     *list.List // Of *bucket, most recently used first
+}
+
+type bucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+}
+
+func newStore(rate float64, burst int) *store {
+	s := &store{
+		rate:     rate,
+		burst:    burst,
+		fillTime: time.Duration(float64(burst) / rate * float64(time.Second)),
+	}
+	for i := range s.shards {
+		s.shards[i] = &storeShard{
+			buckets: make(map[string]*list.Element),
+			lru:     list.New(),
+		}
+	}
+
+	go s.cleanupLoop()
+	return s
+}
+
+// take takes a token from the bucket, if there is one. If not, it
+// returns the number of seconds until one becomes available.
+func (s *store) take(key string, now time.Time) (bool, float64) {
+	shard := s.shard(key)
+	shard.Lock()
+	defer shard.Unlock()
+
+	var b *bucket
+	if element, ok := shard.buckets[key]; ok {
+		shard.lru.MoveToFront(element)
+		b = element.Value.(*bucket)
+	} else {
+		if len(shard.buckets) >= maxBuckets/storeShards {
+			oldest := shard.lru.Back()
+			shard.lru.Remove(oldest)
+			delete(shard.buckets, oldest.Value.(*bucket).key)
+		}
+
+		b = &bucket{
+			key:     key,
+			tokens:  float64(s.burst),
+			updated: now,
+		}
+		shard.buckets[key] = shard.lru.PushFront(b)
+	}
+
+	b.tokens = math.Min(float64(s.burst), b.tokens+now.Sub(b.updated).Seconds()*s.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		return false, (1 - b.tokens) / s.rate
+	}
+
+	b.tokens--
+	return true, 0
+}
+
+func (s *store) shard(key string) *storeShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return s.shards[h.Sum32()%storeShards]
+}
+
+func (s *store) cleanupLoop() {
+	ticker := time.NewTicker(cleanupInterval)
+	stopper := stop.NewStopper(func() {
+		ticker.Stop()
+	})
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.cleanup(now)
+		case <-stopper.ShouldStop():
+			return
+		}
+	}
+}
+
+// cleanup removes the buckets that would be full by now, as
+// they're indistinguishable from buckets that don't exist yet.
+// Those used least recently were updated longest ago.
+func (s *store) cleanup(now time.Time) {
+	for _, shard := range s.shards {
+		shard.Lock()
+		for element := shard.lru.Back(); element != nil; element = shard.lru.Back() {
+			b := element.Value.(*bucket)
+			if now.Sub(b.updated) < s.fillTime {
+				break
+			}
+			shard.lru.Remove(element)
+			delete(shard.buckets, b.key)
+		}
+		shard.Unlock()
+	}
+}
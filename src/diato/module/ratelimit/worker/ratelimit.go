@@ -0,0 +1,186 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"diato/config"
+	ratelimit "diato/module/ratelimit/config"
+	pb "diato/module/ratelimit/pb"
+	"diato/util/hostname"
+	"diato/worker"
+)
+
+const name = "ratelimit"
+
+// Requests are let through if the server takes longer than this
+const takeTimeout = time.Second
+
+// Longer keys are hashed, so they don't bloat the server's memory
+const maxKeyLength = 128
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled bool
+	limits  []*limit // Ordered by name
+	grpc    pb.ModuleRatelimitClient
+}
+
+type limit struct {
+	name string
+	host string // Normalized, so it compares to ContextInfo.Host()
+	*ratelimit.Limit
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if len(config.Ratelimit) == 0 {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	module := &module{
+		enabled: true,
+		limits:  make([]*limit, 0, len(config.Ratelimit)),
+		grpc:    pb.NewModuleRatelimitClient(w.GetGrpcClientConn()),
+	}
+
+	for name, l := range config.Ratelimit {
+		module.limits = append(module.limits, &limit{name, hostname.Normalize(l.Host), l})
+	}
+	sort.Slice(module.limits, func(i, j int) bool {
+		return module.limits[i].name < module.limits[j].name
+	})
+
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+func (m *module) ProcessRequest(req *http.Request) {
+	buckets := make([]*pb.Bucket, 0, len(m.limits))
+	for _, l := range m.limits {
+		if key, ok := l.key(req); ok {
+			buckets = append(buckets, &pb.Bucket{Limit: l.name, Key: key})
+		}
+	}
+
+	if len(buckets) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), takeTimeout)
+	defer cancel()
+
+	res, err := m.grpc.Take(ctx, &pb.TakeRequest{Buckets: buckets})
+	if err != nil {
+		log.Printf("Could not apply rate limits, allowing request: %s", err.Error())
+		return
+	}
+
+	if res.Allowed {
+		return
+	}
+
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+	ctxInfo.Intervene(&worker.Intervention{
+		Status: http.StatusTooManyRequests,
+		Header: http.Header{
+			"Retry-After": []string{strconv.Itoa(int(math.Ceil(res.RetryAfter)))},
+		},
+	})
+}
+
+// key returns the key of the bucket the request takes a
+// token from, or false if the limit doesn't apply to it.
+func (l *limit) key(req *http.Request) (string, bool) {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+	reqPath := path.Clean("/" + req.URL.Path)
+
+	if l.host != "" && l.host != ctxInfo.Host() {
+		return "", false
+	}
+	if l.PathPrefix != "" && !strings.HasPrefix(reqPath, l.PathPrefix) {
+		return "", false
+	}
+
+	var key string
+	switch {
+	case l.Key == "ip":
+		var ok bool
+		if key, ok = ipKey(req.RemoteAddr); !ok {
+			return "", false
+		}
+	case l.Key == "host":
+		key = ctxInfo.Host()
+	case l.Key == "path":
+		key = reqPath
+	case strings.HasPrefix(l.Key, "header:"):
+		key = req.Header.Get(strings.TrimPrefix(l.Key, "header:"))
+		if key == "" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	if len(key) > maxKeyLength {
+		sum := sha256.Sum256([]byte(key))
+		key = hex.EncodeToString(sum[:])
+	}
+
+	return key, true
+}
+
+// ipKey returns the key of the client's address. IPv6 clients are
+// limited by their /64, as they usually have all of it to pick from.
+func ipKey(remoteAddr string) (string, bool) {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return "", false
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return "", false
+	}
+	if ip.To4() != nil {
+		return ip.String(), true
+	}
+
+	return ip.Mask(net.CIDRMask(64, 128)).String() + "/64", true
+}
@@ -0,0 +1,46 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package config
+
+import (
+	"errors"
+
+	"diato/module/rewrite/rules"
+)
+
+type Config struct {
+	Enabled bool
+	Path    string
+}
+
+// Check validates the configuration and the rules file. All
+// problems found are returned, rather than just the first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if c.Path == "" {
+		return append(errs, errors.New("No path was set"))
+	}
+
+	if _, err := rules.ParseFile(c.Path); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
@@ -0,0 +1,139 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package rules parses the file that determines which requests are
+// rewritten or redirected. Each line holds a host, the type of match, a
+// pattern, an action and a target, e.g.:
+//
+//	# <host> <prefix|regex> <pattern> <rewrite|301|302|307|308> <target>
+//	example.com  prefix  /old/                  301      /new/
+//	example.com  regex   ^/blog/(\d+)/([^/]+)$  rewrite  /posts/$1?slug=$2
+//	*            regex   ^/(.*)\.php$           308      https://example.org/$1
+//
+// The rules of a host are evaluated in order, followed by those of '*'.
+// The first rule that matches the path of the request is applied and
+// no others are. A prefix is replaced by the target, keeping what comes
+// after it. Regular expressions are replaced by the target as a whole,
+// in which $1 or ${name} refer to capture groups. Paths are matched as
+// they were requested, so still escaped, e.g. '/caf%C3%A9', and targets
+// are to be escaped likewise.
+//
+// Unless the target holds a query string, the query of the request is
+// kept. Rewritten requests are proxied as usual, with their new path.
+package rules
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	pb "diato/module/rewrite/pb"
+	"diato/util/hostname"
+)
+
+func ParseFile(path string) (*pb.Rules, error) {
+	contents, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Could not read file %s: %s", path, err.Error())
+	}
+
+	rules, err := Parse(contents)
+	if err != nil {
+		return nil, fmt.Errorf("Could not parse file %s: %s", path, err.Error())
+	}
+
+	return rules, nil
+}
+
+func Parse(contents []byte) (*pb.Rules, error) {
+	rules := &pb.Rules{
+		Rules: make([]*pb.Rule, 0),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue // Patterns and targets may hold a '#' themselves
+		}
+
+		rule, err := parseRule(fields)
+		if err != nil {
+			return nil, fmt.Errorf("Line %d: %s", lineNo, err.Error())
+		}
+		rules.Rules = append(rules.Rules, rule)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return rules, nil
+}
+
+func parseRule(fields []string) (*pb.Rule, error) {
+	if len(fields) != 5 {
+		return nil, errors.New("Expected a host, prefix or regex, a pattern, an action and a target")
+	}
+
+	rule := &pb.Rule{
+		Host:    hostname.Normalize(fields[0]),
+		Pattern: fields[2],
+		Target:  fields[4],
+	}
+
+	switch fields[1] {
+	case "prefix":
+		if !strings.HasPrefix(rule.Pattern, "/") {
+			return nil, fmt.Errorf("Prefix '%s' must start with a '/'", rule.Pattern)
+		}
+	case "regex":
+		rule.Regex = true
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return nil, fmt.Errorf("Invalid regular expression '%s': %s", rule.Pattern, err.Error())
+		}
+	default:
+		return nil, fmt.Errorf("Unknown match '%s', expected prefix or regex", fields[1])
+	}
+
+	if fields[3] != "rewrite" {
+		status, err := strconv.Atoi(fields[3])
+		if err != nil || !IsRedirectStatus(status) {
+			return nil, fmt.Errorf("Unknown action '%s', expected rewrite, 301, 302, 307 or 308", fields[3])
+		}
+		rule.Status = uint32(status)
+	}
+
+	// Requests are rewritten to a path, not to another host
+	if rule.Status == 0 && !strings.HasPrefix(rule.Target, "/") {
+		return nil, fmt.Errorf("Rewrite target '%s' must start with a '/'", rule.Target)
+	}
+
+	return rule, nil
+}
+
+func IsRedirectStatus(status int) bool {
+	switch status {
+	case 301, 302, 307, 308:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rules
+
+import (
+	"reflect"
+	"testing"
+
+	pb "diato/module/rewrite/pb"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		want     []*pb.Rule // nil if parsing should fail
+	}{
+		{
+			name:     "empty",
+			contents: "# Nothing but comments\n\n",
+			want:     []*pb.Rule{},
+		},
+		{
+			name:     "prefix redirect",
+			contents: "Example.COM. prefix /old/ 301 /new/",
+			want:     []*pb.Rule{{Host: "example.com", Pattern: "/old/", Status: 301, Target: "/new/"}},
+		},
+		{
+			name:     "regex rewrite",
+			contents: `example.com regex ^/blog/(\d+)/([^/]+)$ rewrite /posts/$1?slug=$2`,
+			want: []*pb.Rule{{
+				Host: "example.com", Regex: true, Pattern: `^/blog/(\d+)/([^/]+)$`, Target: "/posts/$1?slug=$2",
+			}},
+		},
+		{
+			name:     "redirect off site",
+			contents: `* regex ^/(.*)\.php$ 308 https://example.org/$1`,
+			want: []*pb.Rule{{
+				Host: "*", Regex: true, Pattern: `^/(.*)\.php$`, Status: 308, Target: "https://example.org/$1",
+			}},
+		},
+		{
+			name:     "patterns may hold a hash",
+			contents: "example.com prefix /#old 302 /new",
+			want:     []*pb.Rule{{Host: "example.com", Pattern: "/#old", Status: 302, Target: "/new"}},
+		},
+		{name: "too few fields", contents: "example.com prefix /old/ 301"},
+		{name: "too many fields", contents: "example.com prefix /old/ 301 /new/ /newer/"},
+		{name: "relative prefix", contents: "example.com prefix old/ 301 /new/"},
+		{name: "invalid regex", contents: "example.com regex ^/(old 301 /new/"},
+		{name: "unknown match", contents: "example.com glob /old/* 301 /new/"},
+		{name: "unknown action", contents: "example.com prefix /old/ redirect /new/"},
+		{name: "not a redirect status", contents: "example.com prefix /old/ 200 /new/"},
+		{name: "rewrite to another host", contents: "example.com prefix /old/ rewrite https://example.org/"},
+	}
+
+	for _, test := range tests {
+		got, err := Parse([]byte(test.contents))
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("%s: expected an error, got rules %v", test.name, got.Rules)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: could not parse: %s", test.name, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got.Rules, test.want) {
+			t.Errorf("%s: got rules %v, want %v", test.name, got.Rules, test.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rewrite
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"diato/config"
+	pb "diato/module/rewrite/pb"
+	"diato/util/rulesync"
+	"diato/worker"
+)
+
+const name = "rewrite"
+
+func init() {
+	worker.RegisterModule(newModule)
+}
+
+type module struct {
+	*worker.ModuleBase
+
+	enabled bool
+
+	rules *rulesync.Poller // *ruleSet
+}
+
+func newModule(w *worker.Worker, config *config.Config) ([]worker.Module, error) {
+	if !config.Rewrite.Enabled {
+		return []worker.Module{&module{enabled: false}}, nil
+	}
+
+	module := &module{
+		enabled: true,
+	}
+
+	grpc := pb.NewModuleRewriteClient(w.GetGrpcClientConn())
+	var err error
+	module.rules, err = rulesync.NewPoller("rewrite rules", func(ctx context.Context, version uint64) (rulesync.Rules, error) {
+		return grpc.GetRules(ctx, &pb.RulesRequest{Version: version})
+	}, func(res rulesync.Rules) (interface{}, int, error) {
+		rules := res.(*pb.Rules)
+		set, err := newRuleSet(rules)
+		return set, len(rules.Rules), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []worker.Module{module}, nil
+}
+
+func (m *module) Enabled() bool {
+	return m.enabled
+}
+
+func (m *module) Name() string {
+	return name
+}
+
+// RewriteRequest rewrites the path of the request, or redirects the
+// client elsewhere, according to the first rule that matches.
+func (m *module) RewriteRequest(req *http.Request) {
+	ctxInfo := req.Context().Value("diato").(*worker.ContextInfo)
+	set := m.rules.Current().(*ruleSet)
+	r, target := set.match(ctxInfo.Host(), req.URL.EscapedPath())
+	if r == nil {
+		return
+	}
+
+	u, err := parseTarget(target)
+	if err != nil {
+		log.Printf("Could not parse rewrite target '%s': %s", target, err.Error())
+		return
+	}
+	if u.RawQuery == "" && !u.ForceQuery {
+		u.RawQuery = req.URL.RawQuery
+	}
+
+	if r.status == 0 {
+		req.URL.Path = u.Path
+		req.URL.RawPath = u.RawPath
+		req.URL.RawQuery = u.RawQuery
+		return
+	}
+
+	ctxInfo.Intervene(&worker.Intervention{
+		Status: r.status,
+		Header: http.Header{"Location": []string{u.String()}},
+	})
+}
+
+// parseTarget parses a target, which is escaped like the path it was
+// derived from. Clients take what follows a leading '//' for a host,
+// so a rule like '^/(.*)$ 301 /$1' would redirect '//evil.example' off
+// site. Those slashes are therefore collapsed into one.
+func parseTarget(target string) (*url.URL, error) {
+	if strings.HasPrefix(target, "//") {
+		target = "/" + strings.TrimLeft(target, "/")
+	}
+
+	return url.Parse(target)
+}
@@ -0,0 +1,57 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rewrite
+
+import (
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		target   string
+		want     string
+		wantPath string
+	}{
+		{"/new/page", "/new/page", "/new/page"},
+		{"/caf%C3%A9/", "/caf%C3%A9/", "/café/"},
+		{"/a%2Fb", "/a%2Fb", "/a/b"},
+		{"/search?q=a+b", "/search?q=a+b", "/search"},
+		{"/search?", "/search?", "/search"},
+		{"https://example.org/index", "https://example.org/index", "/index"},
+
+		// Would otherwise be taken for a host by clients
+		{"//evil.example/path", "/evil.example/path", "/evil.example/path"},
+		{"///evil.example", "/evil.example", "/evil.example"},
+	}
+
+	for _, test := range tests {
+		u, err := parseTarget(test.target)
+		if err != nil {
+			t.Errorf("parseTarget(%q) returned error: %s", test.target, err.Error())
+			continue
+		}
+
+		if u.Host != "" && u.Scheme == "" {
+			t.Errorf("parseTarget(%q) has host %q", test.target, u.Host)
+		}
+		if got := u.String(); got != test.want {
+			t.Errorf("parseTarget(%q) = %q, want %q", test.target, got, test.want)
+		}
+		if u.Path != test.wantPath {
+			t.Errorf("parseTarget(%q) has path %q, want %q", test.target, u.Path, test.wantPath)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rewrite
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	pb "diato/module/rewrite/pb"
+)
+
+// ruleSet holds the rules as received from the server,
+// in a form that allows for matching them quickly.
+type ruleSet struct {
+	// By host, in the order they're to be evaluated
+	byHost map[string][]*rule
+}
+
+type rule struct {
+	prefix string
+	regex  *regexp.Regexp
+
+	status int // Zero to rewrite
+	target string
+}
+
+func newRuleSet(res *pb.Rules) (*ruleSet, error) {
+	set := &ruleSet{
+		byHost: make(map[string][]*rule),
+	}
+
+	for _, r := range res.Rules {
+		compiled := &rule{
+			status: int(r.Status),
+			target: r.Target,
+		}
+
+		if r.Regex {
+			var err error
+			if compiled.regex, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, fmt.Errorf("Could not parse rule for host '%s': %s", r.Host, err.Error())
+			}
+		} else {
+			compiled.prefix = r.Pattern
+		}
+
+		set.byHost[r.Host] = append(set.byHost[r.Host], compiled)
+	}
+
+	return set, nil
+}
+
+// match returns the first rule that matches the path, and the target
+// with its capture groups filled in. The rules of the host are evaluated
+// first, followed by those that apply to all hosts.
+func (s *ruleSet) match(host, path string) (*rule, string) {
+	for _, h := range []string{host, "*"} {
+		for _, r := range s.byHost[h] {
+			if target, ok := r.match(path); ok {
+				return r, target
+			}
+		}
+	}
+
+	return nil, ""
+}
+
+func (r *rule) match(path string) (string, bool) {
+	if r.regex == nil {
+		if !strings.HasPrefix(path, r.prefix) {
+			return "", false
+		}
+
+		// The remainder of the path goes before any query of the target
+		target, query := r.target, ""
+		if i := strings.Index(target, "?"); i != -1 {
+			target, query = target[:i], target[i:]
+		}
+		return target + path[len(r.prefix):] + query, true
+	}
+
+	submatches := r.regex.FindStringSubmatchIndex(path)
+	if submatches == nil {
+		return "", false
+	}
+
+	return string(r.regex.ExpandString(nil, r.target, path, submatches)), true
+}
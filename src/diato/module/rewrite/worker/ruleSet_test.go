@@ -0,0 +1,70 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rewrite
+
+import (
+	"testing"
+
+	"diato/module/rewrite/rules"
+)
+
+func TestRuleSetMatch(t *testing.T) {
+	parsed, err := rules.Parse([]byte(`
+example.com  prefix  /old/                  301      /new/
+example.com  prefix  /shop                  302      /store?ref=shop
+example.com  regex   ^/blog/(\d+)/([^/]+)$  rewrite  /posts/$1?slug=$2
+*            regex   ^/(.*)\.php$           308      https://example.org/$1
+`))
+	if err != nil {
+		t.Fatalf("Could not parse rules: %s", err.Error())
+	}
+	set, err := newRuleSet(parsed)
+	if err != nil {
+		t.Fatalf("Could not build rule set: %s", err.Error())
+	}
+
+	tests := []struct {
+		host       string
+		path       string
+		wantStatus int // -1 if no rule should match
+		wantTarget string
+	}{
+		{"example.com", "/old/", 301, "/new/"},
+		{"example.com", "/old/page", 301, "/new/page"},
+		{"example.com", "/shop/cart", 302, "/store/cart?ref=shop"},
+		{"example.com", "/blog/12/hello", 0, "/posts/12?slug=hello"},
+		{"example.com", "/blog/12/hello/world", -1, ""},
+		{"example.com", "/index.php", 308, "https://example.org/index"},
+		{"example.org", "/index.php", 308, "https://example.org/index"},
+		{"example.org", "/old/page", -1, ""},
+		{"example.com", "/caf%C3%A9.php", 308, "https://example.org/caf%C3%A9"},
+	}
+
+	for _, test := range tests {
+		r, target := set.match(test.host, test.path)
+		if r == nil {
+			if test.wantStatus != -1 {
+				t.Errorf("match(%q, %q) matched no rule, want status %d", test.host, test.path, test.wantStatus)
+			}
+			continue
+		}
+
+		if r.status != test.wantStatus || target != test.wantTarget {
+			t.Errorf("match(%q, %q) = %d %q, want %d %q",
+				test.host, test.path, r.status, target, test.wantStatus, test.wantTarget)
+		}
+	}
+}
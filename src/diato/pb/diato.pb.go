@@ -10,7 +10,23 @@ It is generated from these files:
 It has these top-level messages:
 	UserBackendRequest
 	UserBackendResponse
+	RoutingHeadersRequest
+	RoutingHeaders
+	GeoIpRequest
+	GeoIpResponse
 	ConfigContents
+	ModuleList
+	MetricsReport
+	Metric
+	AdminStatus
+	AdminListener
+	AdminWorker
+	AdminWorkers
+	AdminModule
+	AdminCertificate
+	AdminCertificates
+	AdminUserBackendStatus
+	AdminRecycleRequest
 */
 package diato
 
@@ -37,6 +53,9 @@ const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type UserBackendRequest struct {
 	Name string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	// Requests may be routed by their path and headers
+	Path    string            `protobuf:"bytes,2,opt,name=path" json:"path,omitempty"`
+	Headers map[string]string `protobuf:"bytes,3,rep,name=headers" json:"headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 }
 
 func (m *UserBackendRequest) Reset()                    { *m = UserBackendRequest{} }
@@ -51,182 +70,1328 @@ func (m *UserBackendRequest) GetName() string {
 	return ""
 }
 
+func (m *UserBackendRequest) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *UserBackendRequest) GetHeaders() map[string]string {
+	if m != nil {
+		return m.Headers
+	}
+	return nil
+}
+
 type UserBackendResponse struct {
-	Server string `protobuf:"bytes,1,opt,name=server" json:"server,omitempty"`
-	Port   uint32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	Server      string `protobuf:"bytes,1,opt,name=server" json:"server,omitempty"`
+	Port        uint32 `protobuf:"varint,2,opt,name=port" json:"port,omitempty"`
+	Passthrough bool   `protobuf:"varint,3,opt,name=passthrough" json:"passthrough,omitempty"`
+	StripPrefix string `protobuf:"bytes,4,opt,name=strip_prefix,json=stripPrefix" json:"strip_prefix,omitempty"`
+	User        string `protobuf:"bytes,5,opt,name=user" json:"user,omitempty"`
+}
+
+func (m *UserBackendResponse) Reset()                    { *m = UserBackendResponse{} }
+func (m *UserBackendResponse) String() string            { return proto.CompactTextString(m) }
+func (*UserBackendResponse) ProtoMessage()               {}
+func (*UserBackendResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *UserBackendResponse) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *UserBackendResponse) GetPort() uint32 {
+	if m != nil {
+		return m.Port
+	}
+	return 0
+}
+
+func (m *UserBackendResponse) GetPassthrough() bool {
+	if m != nil {
+		return m.Passthrough
+	}
+	return false
+}
+
+func (m *UserBackendResponse) GetStripPrefix() string {
+	if m != nil {
+		return m.StripPrefix
+	}
+	return ""
+}
+
+func (m *UserBackendResponse) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+type RoutingHeadersRequest struct {
+	Version uint64 `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+}
+
+func (m *RoutingHeadersRequest) Reset()                    { *m = RoutingHeadersRequest{} }
+func (m *RoutingHeadersRequest) String() string            { return proto.CompactTextString(m) }
+func (*RoutingHeadersRequest) ProtoMessage()               {}
+func (*RoutingHeadersRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+
+func (m *RoutingHeadersRequest) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+type RoutingHeaders struct {
+	Version   uint64   `protobuf:"varint,1,opt,name=version" json:"version,omitempty"`
+	Unchanged bool     `protobuf:"varint,2,opt,name=unchanged" json:"unchanged,omitempty"`
+	Names     []string `protobuf:"bytes,3,rep,name=names" json:"names,omitempty"`
+}
+
+func (m *RoutingHeaders) Reset()                    { *m = RoutingHeaders{} }
+func (m *RoutingHeaders) String() string            { return proto.CompactTextString(m) }
+func (*RoutingHeaders) ProtoMessage()               {}
+func (*RoutingHeaders) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
+
+func (m *RoutingHeaders) GetVersion() uint64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *RoutingHeaders) GetUnchanged() bool {
+	if m != nil {
+		return m.Unchanged
+	}
+	return false
+}
+
+func (m *RoutingHeaders) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+type GeoIpRequest struct {
+	Ip string `protobuf:"bytes,1,opt,name=ip" json:"ip,omitempty"`
+}
+
+func (m *GeoIpRequest) Reset()                    { *m = GeoIpRequest{} }
+func (m *GeoIpRequest) String() string            { return proto.CompactTextString(m) }
+func (*GeoIpRequest) ProtoMessage()               {}
+func (*GeoIpRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *GeoIpRequest) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+// Fields are left empty if they're not known, or if no
+// database providing them was configured.
+type GeoIpResponse struct {
+	ContinentCode  string  `protobuf:"bytes,1,opt,name=continent_code,json=continentCode" json:"continent_code,omitempty"`
+	ContinentName  string  `protobuf:"bytes,2,opt,name=continent_name,json=continentName" json:"continent_name,omitempty"`
+	CountryIsoCode string  `protobuf:"bytes,3,opt,name=country_iso_code,json=countryIsoCode" json:"country_iso_code,omitempty"`
+	CountryName    string  `protobuf:"bytes,4,opt,name=country_name,json=countryName" json:"country_name,omitempty"`
+	RegionName     string  `protobuf:"bytes,5,opt,name=region_name,json=regionName" json:"region_name,omitempty"`
+	CityName       string  `protobuf:"bytes,6,opt,name=city_name,json=cityName" json:"city_name,omitempty"`
+	Latitude       float64 `protobuf:"fixed64,7,opt,name=latitude" json:"latitude,omitempty"`
+	Longitude      float64 `protobuf:"fixed64,8,opt,name=longitude" json:"longitude,omitempty"`
+	Asn            uint32  `protobuf:"varint,9,opt,name=asn" json:"asn,omitempty"`
+	AsOrganization string  `protobuf:"bytes,10,opt,name=as_organization,json=asOrganization" json:"as_organization,omitempty"`
+}
+
+func (m *GeoIpResponse) Reset()                    { *m = GeoIpResponse{} }
+func (m *GeoIpResponse) String() string            { return proto.CompactTextString(m) }
+func (*GeoIpResponse) ProtoMessage()               {}
+func (*GeoIpResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{5} }
+
+func (m *GeoIpResponse) GetContinentCode() string {
+	if m != nil {
+		return m.ContinentCode
+	}
+	return ""
+}
+
+func (m *GeoIpResponse) GetContinentName() string {
+	if m != nil {
+		return m.ContinentName
+	}
+	return ""
+}
+
+func (m *GeoIpResponse) GetCountryIsoCode() string {
+	if m != nil {
+		return m.CountryIsoCode
+	}
+	return ""
+}
+
+func (m *GeoIpResponse) GetCountryName() string {
+	if m != nil {
+		return m.CountryName
+	}
+	return ""
+}
+
+func (m *GeoIpResponse) GetRegionName() string {
+	if m != nil {
+		return m.RegionName
+	}
+	return ""
+}
+
+func (m *GeoIpResponse) GetCityName() string {
+	if m != nil {
+		return m.CityName
+	}
+	return ""
+}
+
+func (m *GeoIpResponse) GetLatitude() float64 {
+	if m != nil {
+		return m.Latitude
+	}
+	return 0
+}
+
+func (m *GeoIpResponse) GetLongitude() float64 {
+	if m != nil {
+		return m.Longitude
+	}
+	return 0
+}
+
+func (m *GeoIpResponse) GetAsn() uint32 {
+	if m != nil {
+		return m.Asn
+	}
+	return 0
+}
+
+func (m *GeoIpResponse) GetAsOrganization() string {
+	if m != nil {
+		return m.AsOrganization
+	}
+	return ""
+}
+
+type ConfigContents struct {
+	Contents []byte `protobuf:"bytes,1,opt,name=contents,proto3" json:"contents,omitempty"`
+}
+
+func (m *ConfigContents) Reset()                    { *m = ConfigContents{} }
+func (m *ConfigContents) String() string            { return proto.CompactTextString(m) }
+func (*ConfigContents) ProtoMessage()               {}
+func (*ConfigContents) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{6} }
+
+func (m *ConfigContents) GetContents() []byte {
+	if m != nil {
+		return m.Contents
+	}
+	return nil
+}
+
+type ModuleList struct {
+	Names []string `protobuf:"bytes,1,rep,name=names" json:"names,omitempty"`
+}
+
+func (m *ModuleList) Reset()                    { *m = ModuleList{} }
+func (m *ModuleList) String() string            { return proto.CompactTextString(m) }
+func (*ModuleList) ProtoMessage()               {}
+func (*ModuleList) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{7} }
+
+func (m *ModuleList) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+type MetricsReport struct {
+	Metrics []*Metric `protobuf:"bytes,1,rep,name=metrics" json:"metrics,omitempty"`
+}
+
+func (m *MetricsReport) Reset()                    { *m = MetricsReport{} }
+func (m *MetricsReport) String() string            { return proto.CompactTextString(m) }
+func (*MetricsReport) ProtoMessage()               {}
+func (*MetricsReport) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{8} }
+
+func (m *MetricsReport) GetMetrics() []*Metric {
+	if m != nil {
+		return m.Metrics
+	}
+	return nil
+}
+
+type Metric struct {
+	Name        string   `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	LabelValues []string `protobuf:"bytes,2,rep,name=label_values,json=labelValues" json:"label_values,omitempty"`
+	// Counters
+	Value float64 `protobuf:"fixed64,3,opt,name=value" json:"value,omitempty"`
+	// Histograms, one count per bucket as opposed to cumulative
+	Buckets []uint64 `protobuf:"varint,4,rep,name=buckets,packed" json:"buckets,omitempty"`
+	Count   uint64   `protobuf:"varint,5,opt,name=count" json:"count,omitempty"`
+	Sum     float64  `protobuf:"fixed64,6,opt,name=sum" json:"sum,omitempty"`
+}
+
+func (m *Metric) Reset()                    { *m = Metric{} }
+func (m *Metric) String() string            { return proto.CompactTextString(m) }
+func (*Metric) ProtoMessage()               {}
+func (*Metric) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{9} }
+
+func (m *Metric) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Metric) GetLabelValues() []string {
+	if m != nil {
+		return m.LabelValues
+	}
+	return nil
+}
+
+func (m *Metric) GetValue() float64 {
+	if m != nil {
+		return m.Value
+	}
+	return 0
+}
+
+func (m *Metric) GetBuckets() []uint64 {
+	if m != nil {
+		return m.Buckets
+	}
+	return nil
+}
+
+func (m *Metric) GetCount() uint64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Metric) GetSum() float64 {
+	if m != nil {
+		return m.Sum
+	}
+	return 0
+}
+
+type AdminStatus struct {
+	Pid              int32            `protobuf:"varint,1,opt,name=pid" json:"pid,omitempty"`
+	StartedAt        int64            `protobuf:"varint,2,opt,name=started_at,json=startedAt" json:"started_at,omitempty"`
+	Listeners        []*AdminListener `protobuf:"bytes,3,rep,name=listeners" json:"listeners,omitempty"`
+	Workers          []*AdminWorker   `protobuf:"bytes,4,rep,name=workers" json:"workers,omitempty"`
+	Modules          []*AdminModule   `protobuf:"bytes,5,rep,name=modules" json:"modules,omitempty"`
+	UserBackendSize  uint32           `protobuf:"varint,6,opt,name=user_backend_size,json=userBackendSize" json:"user_backend_size,omitempty"`
+	CertificateCount uint32           `protobuf:"varint,7,opt,name=certificate_count,json=certificateCount" json:"certificate_count,omitempty"`
+	Draining         bool             `protobuf:"varint,8,opt,name=draining" json:"draining,omitempty"`
+}
+
+func (m *AdminStatus) Reset()                    { *m = AdminStatus{} }
+func (m *AdminStatus) String() string            { return proto.CompactTextString(m) }
+func (*AdminStatus) ProtoMessage()               {}
+func (*AdminStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{10} }
+
+func (m *AdminStatus) GetPid() int32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *AdminStatus) GetStartedAt() int64 {
+	if m != nil {
+		return m.StartedAt
+	}
+	return 0
+}
+
+func (m *AdminStatus) GetListeners() []*AdminListener {
+	if m != nil {
+		return m.Listeners
+	}
+	return nil
+}
+
+func (m *AdminStatus) GetWorkers() []*AdminWorker {
+	if m != nil {
+		return m.Workers
+	}
+	return nil
+}
+
+func (m *AdminStatus) GetModules() []*AdminModule {
+	if m != nil {
+		return m.Modules
+	}
+	return nil
+}
+
+func (m *AdminStatus) GetUserBackendSize() uint32 {
+	if m != nil {
+		return m.UserBackendSize
+	}
+	return 0
+}
+
+func (m *AdminStatus) GetCertificateCount() uint32 {
+	if m != nil {
+		return m.CertificateCount
+	}
+	return 0
+}
+
+func (m *AdminStatus) GetDraining() bool {
+	if m != nil {
+		return m.Draining
+	}
+	return false
+}
+
+type AdminListener struct {
+	Name          string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Bind          string `protobuf:"bytes,2,opt,name=bind" json:"bind,omitempty"`
+	Tls           bool   `protobuf:"varint,3,opt,name=tls" json:"tls,omitempty"`
+	ProxyProtocol bool   `protobuf:"varint,4,opt,name=proxy_protocol,json=proxyProtocol" json:"proxy_protocol,omitempty"`
+}
+
+func (m *AdminListener) Reset()                    { *m = AdminListener{} }
+func (m *AdminListener) String() string            { return proto.CompactTextString(m) }
+func (*AdminListener) ProtoMessage()               {}
+func (*AdminListener) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{11} }
+
+func (m *AdminListener) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AdminListener) GetBind() string {
+	if m != nil {
+		return m.Bind
+	}
+	return ""
+}
+
+func (m *AdminListener) GetTls() bool {
+	if m != nil {
+		return m.Tls
+	}
+	return false
+}
+
+func (m *AdminListener) GetProxyProtocol() bool {
+	if m != nil {
+		return m.ProxyProtocol
+	}
+	return false
+}
+
+type AdminWorker struct {
+	Id          uint32   `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Pid         int32    `protobuf:"varint,2,opt,name=pid" json:"pid,omitempty"`
+	StartedAt   int64    `protobuf:"varint,3,opt,name=started_at,json=startedAt" json:"started_at,omitempty"`
+	Restarts    uint32   `protobuf:"varint,4,opt,name=restarts" json:"restarts,omitempty"`
+	Modules     []string `protobuf:"bytes,5,rep,name=modules" json:"modules,omitempty"`
+	Connections int64    `protobuf:"varint,6,opt,name=connections" json:"connections,omitempty"`
+	Healthy     bool     `protobuf:"varint,7,opt,name=healthy" json:"healthy,omitempty"`
+}
+
+func (m *AdminWorker) Reset()                    { *m = AdminWorker{} }
+func (m *AdminWorker) String() string            { return proto.CompactTextString(m) }
+func (*AdminWorker) ProtoMessage()               {}
+func (*AdminWorker) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{12} }
+
+func (m *AdminWorker) GetId() uint32 {
+	if m != nil {
+		return m.Id
+	}
+	return 0
+}
+
+func (m *AdminWorker) GetPid() int32 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *AdminWorker) GetStartedAt() int64 {
+	if m != nil {
+		return m.StartedAt
+	}
+	return 0
+}
+
+func (m *AdminWorker) GetRestarts() uint32 {
+	if m != nil {
+		return m.Restarts
+	}
+	return 0
+}
+
+func (m *AdminWorker) GetModules() []string {
+	if m != nil {
+		return m.Modules
+	}
+	return nil
+}
+
+func (m *AdminWorker) GetConnections() int64 {
+	if m != nil {
+		return m.Connections
+	}
+	return 0
+}
+
+func (m *AdminWorker) GetHealthy() bool {
+	if m != nil {
+		return m.Healthy
+	}
+	return false
+}
+
+type AdminWorkers struct {
+	Workers []*AdminWorker `protobuf:"bytes,1,rep,name=workers" json:"workers,omitempty"`
+}
+
+func (m *AdminWorkers) Reset()                    { *m = AdminWorkers{} }
+func (m *AdminWorkers) String() string            { return proto.CompactTextString(m) }
+func (*AdminWorkers) ProtoMessage()               {}
+func (*AdminWorkers) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{13} }
+
+func (m *AdminWorkers) GetWorkers() []*AdminWorker {
+	if m != nil {
+		return m.Workers
+	}
+	return nil
+}
+
+type AdminModule struct {
+	Name    string `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Enabled bool   `protobuf:"varint,2,opt,name=enabled" json:"enabled,omitempty"`
+}
+
+func (m *AdminModule) Reset()                    { *m = AdminModule{} }
+func (m *AdminModule) String() string            { return proto.CompactTextString(m) }
+func (*AdminModule) ProtoMessage()               {}
+func (*AdminModule) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{14} }
+
+func (m *AdminModule) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *AdminModule) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
+	}
+	return false
+}
+
+type AdminCertificate struct {
+	Path     string   `protobuf:"bytes,1,opt,name=path" json:"path,omitempty"`
+	Names    []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	LoadedAt int64    `protobuf:"varint,3,opt,name=loaded_at,json=loadedAt" json:"loaded_at,omitempty"`
+	NotAfter int64    `protobuf:"varint,4,opt,name=not_after,json=notAfter" json:"not_after,omitempty"`
+}
+
+func (m *AdminCertificate) Reset()                    { *m = AdminCertificate{} }
+func (m *AdminCertificate) String() string            { return proto.CompactTextString(m) }
+func (*AdminCertificate) ProtoMessage()               {}
+func (*AdminCertificate) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{15} }
+
+func (m *AdminCertificate) GetPath() string {
+	if m != nil {
+		return m.Path
+	}
+	return ""
+}
+
+func (m *AdminCertificate) GetNames() []string {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+func (m *AdminCertificate) GetLoadedAt() int64 {
+	if m != nil {
+		return m.LoadedAt
+	}
+	return 0
+}
+
+func (m *AdminCertificate) GetNotAfter() int64 {
+	if m != nil {
+		return m.NotAfter
+	}
+	return 0
+}
+
+type AdminCertificates struct {
+	Certificates []*AdminCertificate `protobuf:"bytes,1,rep,name=certificates" json:"certificates,omitempty"`
+}
+
+func (m *AdminCertificates) Reset()                    { *m = AdminCertificates{} }
+func (m *AdminCertificates) String() string            { return proto.CompactTextString(m) }
+func (*AdminCertificates) ProtoMessage()               {}
+func (*AdminCertificates) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{16} }
+
+func (m *AdminCertificates) GetCertificates() []*AdminCertificate {
+	if m != nil {
+		return m.Certificates
+	}
+	return nil
+}
+
+type AdminUserBackendStatus struct {
+	Size uint32 `protobuf:"varint,1,opt,name=size" json:"size,omitempty"`
+}
+
+func (m *AdminUserBackendStatus) Reset()                    { *m = AdminUserBackendStatus{} }
+func (m *AdminUserBackendStatus) String() string            { return proto.CompactTextString(m) }
+func (*AdminUserBackendStatus) ProtoMessage()               {}
+func (*AdminUserBackendStatus) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{17} }
+
+func (m *AdminUserBackendStatus) GetSize() uint32 {
+	if m != nil {
+		return m.Size
+	}
+	return 0
+}
+
+type AdminRecycleRequest struct {
+	Ids []uint32 `protobuf:"varint,1,rep,name=ids,packed" json:"ids,omitempty"`
+}
+
+func (m *AdminRecycleRequest) Reset()                    { *m = AdminRecycleRequest{} }
+func (m *AdminRecycleRequest) String() string            { return proto.CompactTextString(m) }
+func (*AdminRecycleRequest) ProtoMessage()               {}
+func (*AdminRecycleRequest) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{18} }
+
+func (m *AdminRecycleRequest) GetIds() []uint32 {
+	if m != nil {
+		return m.Ids
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*UserBackendRequest)(nil), "diato.UserBackendRequest")
+	proto.RegisterType((*UserBackendResponse)(nil), "diato.UserBackendResponse")
+	proto.RegisterType((*RoutingHeadersRequest)(nil), "diato.RoutingHeadersRequest")
+	proto.RegisterType((*RoutingHeaders)(nil), "diato.RoutingHeaders")
+	proto.RegisterType((*GeoIpRequest)(nil), "diato.GeoIpRequest")
+	proto.RegisterType((*GeoIpResponse)(nil), "diato.GeoIpResponse")
+	proto.RegisterType((*ConfigContents)(nil), "diato.ConfigContents")
+	proto.RegisterType((*ModuleList)(nil), "diato.ModuleList")
+	proto.RegisterType((*MetricsReport)(nil), "diato.MetricsReport")
+	proto.RegisterType((*Metric)(nil), "diato.Metric")
+	proto.RegisterType((*AdminStatus)(nil), "diato.AdminStatus")
+	proto.RegisterType((*AdminListener)(nil), "diato.AdminListener")
+	proto.RegisterType((*AdminWorker)(nil), "diato.AdminWorker")
+	proto.RegisterType((*AdminWorkers)(nil), "diato.AdminWorkers")
+	proto.RegisterType((*AdminModule)(nil), "diato.AdminModule")
+	proto.RegisterType((*AdminCertificate)(nil), "diato.AdminCertificate")
+	proto.RegisterType((*AdminCertificates)(nil), "diato.AdminCertificates")
+	proto.RegisterType((*AdminUserBackendStatus)(nil), "diato.AdminUserBackendStatus")
+	proto.RegisterType((*AdminRecycleRequest)(nil), "diato.AdminRecycleRequest")
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for UserBackend service
+
+type UserBackendClient interface {
+	GetServerForUser(ctx context.Context, in *UserBackendRequest, opts ...grpc.CallOption) (*UserBackendResponse, error)
+	// Workers only pass on the headers that requests are routed by
+	GetRoutingHeaders(ctx context.Context, in *RoutingHeadersRequest, opts ...grpc.CallOption) (*RoutingHeaders, error)
+}
+
+type userBackendClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewUserBackendClient(cc *grpc.ClientConn) UserBackendClient {
+	return &userBackendClient{cc}
+}
+
+func (c *userBackendClient) GetServerForUser(ctx context.Context, in *UserBackendRequest, opts ...grpc.CallOption) (*UserBackendResponse, error) {
+	out := new(UserBackendResponse)
+	err := grpc.Invoke(ctx, "/diato.UserBackend/GetServerForUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userBackendClient) GetRoutingHeaders(ctx context.Context, in *RoutingHeadersRequest, opts ...grpc.CallOption) (*RoutingHeaders, error) {
+	out := new(RoutingHeaders)
+	err := grpc.Invoke(ctx, "/diato.UserBackend/GetRoutingHeaders", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for UserBackend service
+
+type UserBackendServer interface {
+	GetServerForUser(context.Context, *UserBackendRequest) (*UserBackendResponse, error)
+	// Workers only pass on the headers that requests are routed by
+	GetRoutingHeaders(context.Context, *RoutingHeadersRequest) (*RoutingHeaders, error)
+}
+
+func RegisterUserBackendServer(s *grpc.Server, srv UserBackendServer) {
+	s.RegisterService(&_UserBackend_serviceDesc, srv)
+}
+
+func _UserBackend_GetServerForUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserBackendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserBackendServer).GetServerForUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.UserBackend/GetServerForUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserBackendServer).GetServerForUser(ctx, req.(*UserBackendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserBackend_GetRoutingHeaders_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RoutingHeadersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserBackendServer).GetRoutingHeaders(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.UserBackend/GetRoutingHeaders",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserBackendServer).GetRoutingHeaders(ctx, req.(*RoutingHeadersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _UserBackend_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "diato.UserBackend",
+	HandlerType: (*UserBackendServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetServerForUser",
+			Handler:    _UserBackend_GetServerForUser_Handler,
+		},
+		{
+			MethodName: "GetRoutingHeaders",
+			Handler:    _UserBackend_GetRoutingHeaders_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "diato.proto",
+}
+
+// Client API for GeoIp service
+
+type GeoIpClient interface {
+	Lookup(ctx context.Context, in *GeoIpRequest, opts ...grpc.CallOption) (*GeoIpResponse, error)
+}
+
+type geoIpClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewGeoIpClient(cc *grpc.ClientConn) GeoIpClient {
+	return &geoIpClient{cc}
+}
+
+func (c *geoIpClient) Lookup(ctx context.Context, in *GeoIpRequest, opts ...grpc.CallOption) (*GeoIpResponse, error) {
+	out := new(GeoIpResponse)
+	err := grpc.Invoke(ctx, "/diato.GeoIp/Lookup", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for GeoIp service
+
+type GeoIpServer interface {
+	Lookup(context.Context, *GeoIpRequest) (*GeoIpResponse, error)
+}
+
+func RegisterGeoIpServer(s *grpc.Server, srv GeoIpServer) {
+	s.RegisterService(&_GeoIp_serviceDesc, srv)
+}
+
+func _GeoIp_Lookup_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GeoIpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GeoIpServer).Lookup(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.GeoIp/Lookup",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GeoIpServer).Lookup(ctx, req.(*GeoIpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _GeoIp_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "diato.GeoIp",
+	HandlerType: (*GeoIpServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Lookup",
+			Handler:    _GeoIp_Lookup_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "diato.proto",
+}
+
+// Client API for Server service
+
+type ServerClient interface {
+	GetConfigContents(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ConfigContents, error)
+	GetDisabledModules(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ModuleList, error)
+	// Workers report the modules they have loaded, so these
+	// can be inspected through the admin API.
+	ReportModules(ctx context.Context, in *ModuleList, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// Workers periodically report what their metrics changed
+	// by since their last report, for the server to expose.
+	ReportMetrics(ctx context.Context, in *MetricsReport, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+}
+
+type serverClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewServerClient(cc *grpc.ClientConn) ServerClient {
+	return &serverClient{cc}
+}
+
+func (c *serverClient) GetConfigContents(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ConfigContents, error) {
+	out := new(ConfigContents)
+	err := grpc.Invoke(ctx, "/diato.Server/GetConfigContents", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverClient) GetDisabledModules(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ModuleList, error) {
+	out := new(ModuleList)
+	err := grpc.Invoke(ctx, "/diato.Server/GetDisabledModules", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverClient) ReportModules(ctx context.Context, in *ModuleList, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/diato.Server/ReportModules", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *serverClient) ReportMetrics(ctx context.Context, in *MetricsReport, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/diato.Server/ReportMetrics", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Server service
+
+type ServerServer interface {
+	GetConfigContents(context.Context, *google_protobuf.Empty) (*ConfigContents, error)
+	GetDisabledModules(context.Context, *google_protobuf.Empty) (*ModuleList, error)
+	// Workers report the modules they have loaded, so these
+	// can be inspected through the admin API.
+	ReportModules(context.Context, *ModuleList) (*google_protobuf.Empty, error)
+	// Workers periodically report what their metrics changed
+	// by since their last report, for the server to expose.
+	ReportMetrics(context.Context, *MetricsReport) (*google_protobuf.Empty, error)
+}
+
+func RegisterServerServer(s *grpc.Server, srv ServerServer) {
+	s.RegisterService(&_Server_serviceDesc, srv)
+}
+
+func _Server_GetConfigContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerServer).GetConfigContents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Server/GetConfigContents",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerServer).GetConfigContents(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Server_GetDisabledModules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerServer).GetDisabledModules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Server/GetDisabledModules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerServer).GetDisabledModules(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Server_ReportModules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ModuleList)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ServerServer).ReportModules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Server/ReportModules",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerServer).ReportModules(ctx, req.(*ModuleList))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *UserBackendResponse) Reset()                    { *m = UserBackendResponse{} }
-func (m *UserBackendResponse) String() string            { return proto.CompactTextString(m) }
-func (*UserBackendResponse) ProtoMessage()               {}
-func (*UserBackendResponse) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
-
-func (m *UserBackendResponse) GetServer() string {
-	if m != nil {
-		return m.Server
+func _Server_ReportMetrics_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MetricsReport)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	return ""
+	if interceptor == nil {
+		return srv.(ServerServer).ReportMetrics(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Server/ReportMetrics",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ServerServer).ReportMetrics(ctx, req.(*MetricsReport))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *UserBackendResponse) GetPort() uint32 {
-	if m != nil {
-		return m.Port
-	}
-	return 0
+var _Server_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "diato.Server",
+	HandlerType: (*ServerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetConfigContents",
+			Handler:    _Server_GetConfigContents_Handler,
+		},
+		{
+			MethodName: "GetDisabledModules",
+			Handler:    _Server_GetDisabledModules_Handler,
+		},
+		{
+			MethodName: "ReportModules",
+			Handler:    _Server_ReportModules_Handler,
+		},
+		{
+			MethodName: "ReportMetrics",
+			Handler:    _Server_ReportMetrics_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "diato.proto",
 }
 
-type ConfigContents struct {
-	Contents []byte `protobuf:"bytes,1,opt,name=contents,proto3" json:"contents,omitempty"`
+// Client API for Admin service
+
+type AdminClient interface {
+	GetStatus(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminStatus, error)
+	GetWorkers(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminWorkers, error)
+	GetCertificates(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminCertificates, error)
+	ReloadUserBackend(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminUserBackendStatus, error)
+	LookupUser(ctx context.Context, in *UserBackendRequest, opts ...grpc.CallOption) (*UserBackendResponse, error)
+	// Gracefully restarts the given workers one by one,
+	// or all of them if no ids were specified.
+	RecycleWorkers(ctx context.Context, in *AdminRecycleRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	// Modules are (de)activated by recycling the workers
+	SetModuleEnabled(ctx context.Context, in *AdminModule, opts ...grpc.CallOption) (*AdminModule, error)
+	// Stops accepting new connections on all listeners,
+	// while existing connections are left alone.
+	Drain(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
+	Stop(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error)
 }
 
-func (m *ConfigContents) Reset()                    { *m = ConfigContents{} }
-func (m *ConfigContents) String() string            { return proto.CompactTextString(m) }
-func (*ConfigContents) ProtoMessage()               {}
-func (*ConfigContents) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+type adminClient struct {
+	cc *grpc.ClientConn
+}
 
-func (m *ConfigContents) GetContents() []byte {
-	if m != nil {
-		return m.Contents
+func NewAdminClient(cc *grpc.ClientConn) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) GetStatus(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminStatus, error) {
+	out := new(AdminStatus)
+	err := grpc.Invoke(ctx, "/diato.Admin/GetStatus", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return out, nil
 }
 
-func init() {
-	proto.RegisterType((*UserBackendRequest)(nil), "diato.UserBackendRequest")
-	proto.RegisterType((*UserBackendResponse)(nil), "diato.UserBackendResponse")
-	proto.RegisterType((*ConfigContents)(nil), "diato.ConfigContents")
+func (c *adminClient) GetWorkers(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminWorkers, error) {
+	out := new(AdminWorkers)
+	err := grpc.Invoke(ctx, "/diato.Admin/GetWorkers", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (c *adminClient) GetCertificates(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminCertificates, error) {
+	out := new(AdminCertificates)
+	err := grpc.Invoke(ctx, "/diato.Admin/GetCertificates", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (c *adminClient) ReloadUserBackend(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*AdminUserBackendStatus, error) {
+	out := new(AdminUserBackendStatus)
+	err := grpc.Invoke(ctx, "/diato.Admin/ReloadUserBackend", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-// Client API for UserBackend service
+func (c *adminClient) LookupUser(ctx context.Context, in *UserBackendRequest, opts ...grpc.CallOption) (*UserBackendResponse, error) {
+	out := new(UserBackendResponse)
+	err := grpc.Invoke(ctx, "/diato.Admin/LookupUser", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
 
-type UserBackendClient interface {
-	GetServerForUser(ctx context.Context, in *UserBackendRequest, opts ...grpc.CallOption) (*UserBackendResponse, error)
+func (c *adminClient) RecycleWorkers(ctx context.Context, in *AdminRecycleRequest, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/diato.Admin/RecycleWorkers", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-type userBackendClient struct {
-	cc *grpc.ClientConn
+func (c *adminClient) SetModuleEnabled(ctx context.Context, in *AdminModule, opts ...grpc.CallOption) (*AdminModule, error) {
+	out := new(AdminModule)
+	err := grpc.Invoke(ctx, "/diato.Admin/SetModuleEnabled", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func NewUserBackendClient(cc *grpc.ClientConn) UserBackendClient {
-	return &userBackendClient{cc}
+func (c *adminClient) Drain(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/diato.Admin/Drain", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
 }
 
-func (c *userBackendClient) GetServerForUser(ctx context.Context, in *UserBackendRequest, opts ...grpc.CallOption) (*UserBackendResponse, error) {
-	out := new(UserBackendResponse)
-	err := grpc.Invoke(ctx, "/diato.UserBackend/GetServerForUser", in, out, c.cc, opts...)
+func (c *adminClient) Stop(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*google_protobuf.Empty, error) {
+	out := new(google_protobuf.Empty)
+	err := grpc.Invoke(ctx, "/diato.Admin/Stop", in, out, c.cc, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-// Server API for UserBackend service
+// Server API for Admin service
 
-type UserBackendServer interface {
-	GetServerForUser(context.Context, *UserBackendRequest) (*UserBackendResponse, error)
+type AdminServer interface {
+	GetStatus(context.Context, *google_protobuf.Empty) (*AdminStatus, error)
+	GetWorkers(context.Context, *google_protobuf.Empty) (*AdminWorkers, error)
+	GetCertificates(context.Context, *google_protobuf.Empty) (*AdminCertificates, error)
+	ReloadUserBackend(context.Context, *google_protobuf.Empty) (*AdminUserBackendStatus, error)
+	LookupUser(context.Context, *UserBackendRequest) (*UserBackendResponse, error)
+	// Gracefully restarts the given workers one by one,
+	// or all of them if no ids were specified.
+	RecycleWorkers(context.Context, *AdminRecycleRequest) (*google_protobuf.Empty, error)
+	// Modules are (de)activated by recycling the workers
+	SetModuleEnabled(context.Context, *AdminModule) (*AdminModule, error)
+	// Stops accepting new connections on all listeners,
+	// while existing connections are left alone.
+	Drain(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
+	Stop(context.Context, *google_protobuf.Empty) (*google_protobuf.Empty, error)
 }
 
-func RegisterUserBackendServer(s *grpc.Server, srv UserBackendServer) {
-	s.RegisterService(&_UserBackend_serviceDesc, srv)
+func RegisterAdminServer(s *grpc.Server, srv AdminServer) {
+	s.RegisterService(&_Admin_serviceDesc, srv)
 }
 
-func _UserBackend_GetServerForUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(UserBackendRequest)
+func _Admin_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(UserBackendServer).GetServerForUser(ctx, in)
+		return srv.(AdminServer).GetStatus(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/diato.UserBackend/GetServerForUser",
+		FullMethod: "/diato.Admin/GetStatus",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(UserBackendServer).GetServerForUser(ctx, req.(*UserBackendRequest))
+		return srv.(AdminServer).GetStatus(ctx, req.(*google_protobuf.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _UserBackend_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "diato.UserBackend",
-	HandlerType: (*UserBackendServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "GetServerForUser",
-			Handler:    _UserBackend_GetServerForUser_Handler,
-		},
-	},
-	Streams:  []grpc.StreamDesc{},
-	Metadata: "diato.proto",
+func _Admin_GetWorkers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetWorkers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/GetWorkers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetWorkers(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Client API for Server service
-
-type ServerClient interface {
-	GetConfigContents(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ConfigContents, error)
+func _Admin_GetCertificates_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetCertificates(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/GetCertificates",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetCertificates(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-type serverClient struct {
-	cc *grpc.ClientConn
+func _Admin_ReloadUserBackend_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ReloadUserBackend(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/ReloadUserBackend",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ReloadUserBackend(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func NewServerClient(cc *grpc.ClientConn) ServerClient {
-	return &serverClient{cc}
+func _Admin_LookupUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserBackendRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).LookupUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/LookupUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).LookupUser(ctx, req.(*UserBackendRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (c *serverClient) GetConfigContents(ctx context.Context, in *google_protobuf.Empty, opts ...grpc.CallOption) (*ConfigContents, error) {
-	out := new(ConfigContents)
-	err := grpc.Invoke(ctx, "/diato.Server/GetConfigContents", in, out, c.cc, opts...)
-	if err != nil {
+func _Admin_RecycleWorkers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminRecycleRequest)
+	if err := dec(in); err != nil {
 		return nil, err
 	}
-	return out, nil
+	if interceptor == nil {
+		return srv.(AdminServer).RecycleWorkers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/RecycleWorkers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).RecycleWorkers(ctx, req.(*AdminRecycleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-// Server API for Server service
-
-type ServerServer interface {
-	GetConfigContents(context.Context, *google_protobuf.Empty) (*ConfigContents, error)
+func _Admin_SetModuleEnabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdminModule)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).SetModuleEnabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/SetModuleEnabled",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).SetModuleEnabled(ctx, req.(*AdminModule))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func RegisterServerServer(s *grpc.Server, srv ServerServer) {
-	s.RegisterService(&_Server_serviceDesc, srv)
+func _Admin_Drain_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(google_protobuf.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Drain(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/diato.Admin/Drain",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Drain(ctx, req.(*google_protobuf.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func _Server_GetConfigContents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+func _Admin_Stop_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(google_protobuf.Empty)
 	if err := dec(in); err != nil {
 		return nil, err
 	}
 	if interceptor == nil {
-		return srv.(ServerServer).GetConfigContents(ctx, in)
+		return srv.(AdminServer).Stop(ctx, in)
 	}
 	info := &grpc.UnaryServerInfo{
 		Server:     srv,
-		FullMethod: "/diato.Server/GetConfigContents",
+		FullMethod: "/diato.Admin/Stop",
 	}
 	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ServerServer).GetConfigContents(ctx, req.(*google_protobuf.Empty))
+		return srv.(AdminServer).Stop(ctx, req.(*google_protobuf.Empty))
 	}
 	return interceptor(ctx, in, info, handler)
 }
 
-var _Server_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "diato.Server",
-	HandlerType: (*ServerServer)(nil),
+var _Admin_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "diato.Admin",
+	HandlerType: (*AdminServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
-			MethodName: "GetConfigContents",
-			Handler:    _Server_GetConfigContents_Handler,
+			MethodName: "GetStatus",
+			Handler:    _Admin_GetStatus_Handler,
+		},
+		{
+			MethodName: "GetWorkers",
+			Handler:    _Admin_GetWorkers_Handler,
+		},
+		{
+			MethodName: "GetCertificates",
+			Handler:    _Admin_GetCertificates_Handler,
+		},
+		{
+			MethodName: "ReloadUserBackend",
+			Handler:    _Admin_ReloadUserBackend_Handler,
+		},
+		{
+			MethodName: "LookupUser",
+			Handler:    _Admin_LookupUser_Handler,
+		},
+		{
+			MethodName: "RecycleWorkers",
+			Handler:    _Admin_RecycleWorkers_Handler,
+		},
+		{
+			MethodName: "SetModuleEnabled",
+			Handler:    _Admin_SetModuleEnabled_Handler,
+		},
+		{
+			MethodName: "Drain",
+			Handler:    _Admin_Drain_Handler,
+		},
+		{
+			MethodName: "Stop",
+			Handler:    _Admin_Stop_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
@@ -236,22 +1401,91 @@ var _Server_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("diato.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 264 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x50, 0xc1, 0x4a, 0xc3, 0x40,
-	0x10, 0x6d, 0x44, 0x83, 0x4e, 0x55, 0x74, 0xc5, 0x52, 0x73, 0x2a, 0x39, 0xe5, 0x20, 0x1b, 0x68,
-	0xbf, 0x40, 0xab, 0xf6, 0x20, 0x78, 0x88, 0x78, 0xf1, 0x96, 0xa4, 0xd3, 0x35, 0xd8, 0xec, 0xac,
-	0xbb, 0x13, 0xa1, 0x7f, 0x2f, 0xd9, 0x44, 0xb1, 0xda, 0xcb, 0xf2, 0x1e, 0xef, 0xcd, 0xdb, 0x99,
-	0x07, 0xc3, 0x65, 0x95, 0x33, 0x49, 0x63, 0x89, 0x49, 0x1c, 0x78, 0x12, 0xcd, 0x54, 0xc5, 0x6f,
-	0x4d, 0x21, 0x4b, 0xaa, 0x53, 0x45, 0xeb, 0x5c, 0xab, 0xd4, 0xeb, 0x45, 0xb3, 0x4a, 0x0d, 0x6f,
-	0x0c, 0xba, 0x14, 0x6b, 0xc3, 0x9b, 0xee, 0xed, 0x66, 0xe3, 0x04, 0xc4, 0x8b, 0x43, 0x7b, 0x9b,
-	0x97, 0xef, 0xa8, 0x97, 0x19, 0x7e, 0x34, 0xe8, 0x58, 0x08, 0xd8, 0xd7, 0x79, 0x8d, 0xe3, 0x60,
-	0x12, 0x24, 0x47, 0x99, 0xc7, 0xf1, 0x0d, 0x5c, 0x6c, 0x39, 0x9d, 0x21, 0xed, 0x50, 0x8c, 0x20,
-	0x74, 0x68, 0x3f, 0xd1, 0xf6, 0xe6, 0x9e, 0xb5, 0x11, 0x86, 0x2c, 0x8f, 0xf7, 0x26, 0x41, 0x72,
-	0x92, 0x79, 0x1c, 0x5f, 0xc3, 0xe9, 0x9c, 0xf4, 0xaa, 0x52, 0x73, 0xd2, 0x8c, 0x9a, 0x9d, 0x88,
-	0xe0, 0xb0, 0xec, 0xb1, 0x9f, 0x3f, 0xce, 0x7e, 0xf8, 0xf4, 0x15, 0x86, 0xbf, 0x3e, 0x14, 0x8f,
-	0x70, 0xb6, 0x40, 0x7e, 0xf6, 0xe9, 0x0f, 0x64, 0x5b, 0x49, 0x5c, 0xc9, 0xae, 0x87, 0xff, 0x27,
-	0x44, 0xd1, 0x2e, 0xa9, 0xdb, 0x39, 0x1e, 0x4c, 0x9f, 0x20, 0xec, 0x92, 0xc4, 0x1d, 0x9c, 0x2f,
-	0x90, 0xff, 0xac, 0x35, 0x92, 0x8a, 0x48, 0xad, 0x51, 0x7e, 0x17, 0x28, 0xef, 0xdb, 0xce, 0xa2,
-	0xcb, 0x3e, 0x74, 0xdb, 0x1e, 0x0f, 0x8a, 0xd0, 0x1b, 0x67, 0x5f, 0x01, 0x00, 0x00, 0xff, 0xff,
-	0x19, 0x21, 0x5b, 0xb1, 0x98, 0x01, 0x00, 0x00,
+	// 1365 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x56, 0xdf, 0x6e, 0x1b, 0x45,
+	0x17, 0xcf, 0xfa, 0x4f, 0x62, 0x1f, 0xdb, 0xa9, 0x33, 0x49, 0xfb, 0xed, 0xe7, 0xaf, 0xfd, 0x70,
+	0x57, 0x82, 0x46, 0x50, 0x39, 0xc2, 0x15, 0x52, 0xff, 0x09, 0x08, 0x69, 0x1a, 0x0a, 0x29, 0x54,
+	0x13, 0x01, 0x77, 0x58, 0xeb, 0xdd, 0x89, 0x3d, 0xca, 0x7a, 0x66, 0xd9, 0x99, 0x0d, 0x75, 0x9f,
+	0x82, 0x3b, 0x24, 0x1e, 0x80, 0x87, 0xe0, 0x0a, 0x89, 0x77, 0xe0, 0x8e, 0x77, 0x41, 0x73, 0x66,
+	0xd7, 0x5e, 0x27, 0x5e, 0x10, 0xe2, 0xc6, 0x3a, 0xe7, 0x77, 0x7e, 0x73, 0x76, 0xe6, 0xfc, 0x35,
+	0xb4, 0x42, 0xee, 0x6b, 0x39, 0x88, 0x13, 0xa9, 0x25, 0xa9, 0xa3, 0xd2, 0x7b, 0x30, 0xe1, 0x7a,
+	0x9a, 0x8e, 0x07, 0x81, 0x9c, 0x1d, 0x4c, 0x64, 0xe4, 0x8b, 0xc9, 0x01, 0xda, 0xc7, 0xe9, 0xf9,
+	0x41, 0xac, 0xe7, 0x31, 0x53, 0x07, 0x6c, 0x16, 0xeb, 0xb9, 0xfd, 0xb5, 0x67, 0xbd, 0x5f, 0x1c,
+	0x20, 0x5f, 0x29, 0x96, 0x7c, 0xe2, 0x07, 0x17, 0x4c, 0x84, 0x94, 0x7d, 0x97, 0x32, 0xa5, 0x09,
+	0x81, 0x9a, 0xf0, 0x67, 0xcc, 0x75, 0xfa, 0xce, 0x7e, 0x93, 0xa2, 0x6c, 0xb0, 0xd8, 0xd7, 0x53,
+	0xb7, 0x62, 0x31, 0x23, 0x93, 0x8f, 0x61, 0x6b, 0xca, 0xfc, 0x90, 0x25, 0xca, 0xad, 0xf6, 0xab,
+	0xfb, 0xad, 0xe1, 0x3b, 0x03, 0x7b, 0xb3, 0xeb, 0x3e, 0x07, 0x9f, 0x5a, 0xe2, 0xb1, 0xd0, 0xc9,
+	0x9c, 0xe6, 0xc7, 0x7a, 0x8f, 0xa1, 0x5d, 0x34, 0x90, 0x2e, 0x54, 0x2f, 0xd8, 0x3c, 0xfb, 0xb0,
+	0x11, 0xc9, 0x1e, 0xd4, 0x2f, 0xfd, 0x28, 0x65, 0xd9, 0x87, 0xad, 0xf2, 0xb8, 0xf2, 0xd0, 0xf1,
+	0x7e, 0x72, 0x60, 0x77, 0xe5, 0x43, 0x2a, 0x96, 0x42, 0x31, 0x72, 0x0b, 0x36, 0x15, 0x4b, 0x2e,
+	0x59, 0x92, 0xb9, 0xc9, 0x34, 0x7c, 0x81, 0x4c, 0x34, 0x3a, 0xea, 0x50, 0x94, 0x49, 0x1f, 0x5a,
+	0xb1, 0xaf, 0x94, 0x9e, 0x26, 0x32, 0x9d, 0x4c, 0xdd, 0x6a, 0xdf, 0xd9, 0x6f, 0xd0, 0x22, 0x44,
+	0xee, 0x42, 0x5b, 0xe9, 0x84, 0xc7, 0xa3, 0x38, 0x61, 0xe7, 0xfc, 0xb5, 0x5b, 0x43, 0x9f, 0x2d,
+	0xc4, 0x5e, 0x21, 0x64, 0x1c, 0xa7, 0x8a, 0x25, 0x6e, 0xdd, 0x86, 0xc6, 0xc8, 0xde, 0xfb, 0x70,
+	0x93, 0xca, 0x54, 0x73, 0x31, 0xc9, 0xde, 0x97, 0xc7, 0xd6, 0x85, 0xad, 0x4b, 0x96, 0x28, 0x2e,
+	0x05, 0x5e, 0xaf, 0x46, 0x73, 0xd5, 0xfb, 0x16, 0xb6, 0x57, 0x8f, 0x94, 0x73, 0xc9, 0x6d, 0x68,
+	0xa6, 0x22, 0x98, 0xfa, 0x62, 0xc2, 0x42, 0x7c, 0x50, 0x83, 0x2e, 0x01, 0x13, 0x33, 0x93, 0x33,
+	0x9b, 0x95, 0x26, 0xb5, 0x8a, 0xf7, 0x7f, 0x68, 0x9f, 0x30, 0xf9, 0x22, 0xce, 0x6f, 0xb2, 0x0d,
+	0x15, 0x1e, 0x67, 0x31, 0xaa, 0xf0, 0xd8, 0xfb, 0xa3, 0x02, 0x9d, 0x8c, 0x90, 0x45, 0xf2, 0x6d,
+	0xd8, 0x0e, 0xa4, 0xd0, 0x5c, 0x30, 0xa1, 0x47, 0x81, 0x0c, 0xf3, 0x8a, 0xe8, 0x2c, 0xd0, 0x23,
+	0x19, 0x5e, 0xa1, 0x61, 0xe1, 0x54, 0xae, 0xd0, 0xbe, 0x30, 0x15, 0xb4, 0x0f, 0xdd, 0x40, 0xa6,
+	0x26, 0xcd, 0x23, 0xae, 0xa4, 0xf5, 0x57, 0x45, 0xe2, 0x76, 0x86, 0xbf, 0x50, 0x12, 0x1d, 0xde,
+	0x85, 0x76, 0xce, 0x44, 0x77, 0x59, 0xcc, 0x33, 0x0c, 0x9d, 0xbd, 0x05, 0xad, 0x84, 0x4d, 0xb8,
+	0x14, 0x96, 0x61, 0x43, 0x0f, 0x16, 0x42, 0xc2, 0xff, 0xa0, 0x19, 0x70, 0x9d, 0x39, 0xd8, 0x44,
+	0x73, 0xc3, 0x00, 0x68, 0xec, 0x41, 0x23, 0xf2, 0x35, 0xd7, 0x69, 0xc8, 0xdc, 0xad, 0xbe, 0xb3,
+	0xef, 0xd0, 0x85, 0x6e, 0x42, 0x1b, 0x49, 0x31, 0xb1, 0xc6, 0x06, 0x1a, 0x97, 0x80, 0x29, 0x50,
+	0x5f, 0x09, 0xb7, 0x89, 0x35, 0x64, 0x44, 0x72, 0x0f, 0x6e, 0xf8, 0x6a, 0x24, 0x93, 0x89, 0x2f,
+	0xf8, 0x1b, 0x5f, 0x9b, 0x64, 0x81, 0x7d, 0x95, 0xaf, 0xbe, 0x2c, 0xa0, 0xde, 0x7d, 0xd8, 0x3e,
+	0x92, 0xe2, 0x9c, 0x4f, 0x8e, 0xa4, 0xd0, 0x4c, 0x68, 0x65, 0xae, 0x11, 0x64, 0x32, 0x46, 0xb6,
+	0x4d, 0x17, 0xba, 0xe7, 0x01, 0xbc, 0x94, 0x61, 0x1a, 0xb1, 0x53, 0xae, 0xf4, 0x32, 0xa3, 0x4e,
+	0x31, 0xa3, 0x0f, 0xa1, 0xf3, 0x92, 0xe9, 0x84, 0x07, 0x8a, 0x32, 0x2c, 0xe7, 0x7b, 0xb0, 0x35,
+	0xb3, 0x00, 0x12, 0x5b, 0xc3, 0x4e, 0xd6, 0x90, 0x96, 0x46, 0x73, 0xab, 0xf7, 0xa3, 0x03, 0x9b,
+	0x16, 0x5b, 0xdb, 0xec, 0x77, 0xa1, 0x1d, 0xf9, 0x63, 0x16, 0x8d, 0xb0, 0xdb, 0x94, 0x5b, 0xc1,
+	0xaf, 0xb6, 0x10, 0xfb, 0x1a, 0xa1, 0x65, 0x5f, 0x56, 0x31, 0x44, 0x56, 0x31, 0x15, 0x3b, 0x4e,
+	0x83, 0x0b, 0xa6, 0x95, 0x5b, 0xeb, 0x57, 0x4d, 0xc5, 0x66, 0xaa, 0xe1, 0x63, 0xfe, 0x30, 0x55,
+	0x35, 0x6a, 0x15, 0x13, 0x4e, 0x95, 0xce, 0x30, 0x3f, 0x0e, 0x35, 0xa2, 0xf7, 0x5b, 0x05, 0x5a,
+	0x87, 0xe1, 0x8c, 0x8b, 0x33, 0xed, 0xeb, 0x54, 0x19, 0x46, 0xcc, 0x43, 0xbc, 0x5d, 0x9d, 0x1a,
+	0x91, 0xdc, 0x01, 0x50, 0xda, 0x4f, 0x34, 0x0b, 0x47, 0xbe, 0xed, 0xe6, 0x2a, 0x6d, 0x66, 0xc8,
+	0xa1, 0x26, 0x43, 0x68, 0x46, 0x5c, 0x69, 0x26, 0x96, 0x63, 0x69, 0x2f, 0x8b, 0x02, 0xfa, 0x3d,
+	0xcd, 0x8c, 0x74, 0x49, 0x23, 0xf7, 0x61, 0xeb, 0x7b, 0x99, 0x5c, 0xb0, 0xc4, 0x5e, 0xbb, 0x35,
+	0x24, 0xc5, 0x13, 0xdf, 0xa0, 0x89, 0xe6, 0x14, 0xc3, 0x9e, 0x61, 0x6a, 0x94, 0x5b, 0xbf, 0xce,
+	0xb6, 0x59, 0xa3, 0x39, 0x85, 0xbc, 0x0b, 0x3b, 0x66, 0x22, 0x8c, 0xc6, 0x76, 0x4c, 0x8d, 0x14,
+	0x7f, 0x63, 0x0b, 0xb2, 0x43, 0x6f, 0xa4, 0xcb, 0xf1, 0x75, 0xc6, 0xdf, 0x30, 0xf2, 0x1e, 0xec,
+	0x04, 0x2c, 0xd1, 0xfc, 0x9c, 0x07, 0xbe, 0x66, 0x23, 0x1b, 0xb0, 0x2d, 0xe4, 0x76, 0x0b, 0x86,
+	0x23, 0x8c, 0x5d, 0x0f, 0x1a, 0x61, 0xe2, 0x73, 0xc1, 0xc5, 0x04, 0xeb, 0xb4, 0x41, 0x17, 0xba,
+	0x17, 0x43, 0x67, 0xe5, 0xb1, 0x65, 0x23, 0x7d, 0xcc, 0x45, 0x98, 0x8f, 0x74, 0x23, 0x9b, 0x70,
+	0xeb, 0x48, 0x65, 0x83, 0xd0, 0x88, 0xa6, 0xbb, 0xe3, 0x44, 0xbe, 0x9e, 0x8f, 0x70, 0x65, 0x04,
+	0x32, 0xc2, 0x76, 0x6c, 0xd0, 0x0e, 0xa2, 0xaf, 0x32, 0xd0, 0xfb, 0xd5, 0x81, 0x56, 0x21, 0x5a,
+	0x38, 0x5d, 0x6c, 0xda, 0x3a, 0xb4, 0xc2, 0xc3, 0x3c, 0x8f, 0x95, 0xb2, 0x3c, 0x56, 0xaf, 0xe6,
+	0xb1, 0x07, 0x8d, 0x84, 0xa1, 0xaa, 0xf0, 0x8b, 0x1d, 0xba, 0xd0, 0x4d, 0x99, 0x15, 0x33, 0xd0,
+	0x5c, 0x46, 0xbb, 0x0f, 0xad, 0x40, 0x0a, 0xc1, 0x02, 0xd3, 0x72, 0x0a, 0xe3, 0x5c, 0xa5, 0x45,
+	0xc8, 0x9c, 0x9d, 0x32, 0x3f, 0xd2, 0xd3, 0x39, 0x46, 0xb6, 0x41, 0x73, 0xd5, 0x7b, 0x0a, 0xed,
+	0xc2, 0x0b, 0x56, 0xaa, 0xc2, 0xf9, 0xdb, 0xaa, 0xf0, 0x9e, 0x40, 0xab, 0x90, 0xff, 0xb5, 0x01,
+	0x77, 0x61, 0x8b, 0x09, 0x7f, 0x1c, 0x2d, 0x66, 0x76, 0xae, 0x7a, 0x97, 0xd0, 0xc5, 0xc3, 0x47,
+	0xcb, 0x24, 0x2f, 0x36, 0xae, 0x53, 0xd8, 0xb8, 0x8b, 0x39, 0x50, 0x29, 0xcc, 0x01, 0x33, 0xeb,
+	0x22, 0xe9, 0x87, 0xc5, 0x40, 0x36, 0x2c, 0x70, 0xa8, 0x8d, 0x51, 0x48, 0x3d, 0xf2, 0xcf, 0x35,
+	0x4b, 0x30, 0x90, 0x55, 0xda, 0x10, 0x52, 0x1f, 0x1a, 0xdd, 0x7b, 0x05, 0x3b, 0x57, 0xbf, 0xab,
+	0xc8, 0x13, 0x68, 0x17, 0x8a, 0x2d, 0x7f, 0xfc, 0x7f, 0x8a, 0x8f, 0x2f, 0xf0, 0xe9, 0x0a, 0xd9,
+	0xbb, 0x0f, 0xb7, 0x90, 0x51, 0xd8, 0xcc, 0x59, 0x27, 0x13, 0xa8, 0x61, 0xed, 0xdb, 0x9a, 0x40,
+	0xd9, 0xbb, 0x07, 0xbb, 0xc8, 0xa6, 0x2c, 0x98, 0x07, 0x11, 0xcb, 0x57, 0x53, 0x17, 0xaa, 0x3c,
+	0xb4, 0x1f, 0xee, 0x50, 0x23, 0x0e, 0x7f, 0x76, 0xa0, 0x55, 0x70, 0x49, 0x3e, 0x87, 0xee, 0x09,
+	0xd3, 0x67, 0xb8, 0xd9, 0x9f, 0xcb, 0xc4, 0x98, 0xc8, 0x7f, 0x4b, 0xff, 0x7d, 0xf4, 0x7a, 0xeb,
+	0x4c, 0x76, 0xcb, 0x79, 0x1b, 0xe4, 0x33, 0xd8, 0x39, 0x61, 0xfa, 0xca, 0xf2, 0xbd, 0x9d, 0x1d,
+	0x59, 0xbb, 0xc6, 0x7b, 0x37, 0xd7, 0x5a, 0xbd, 0x8d, 0xe1, 0x87, 0x50, 0xc7, 0x25, 0x4a, 0x3e,
+	0x80, 0xcd, 0x53, 0x29, 0x2f, 0xd2, 0x98, 0xec, 0x66, 0xdc, 0xe2, 0xf6, 0xed, 0xed, 0xad, 0x82,
+	0xf9, 0x5d, 0x86, 0x3f, 0x54, 0x60, 0xd3, 0x3e, 0x8b, 0x3c, 0xc3, 0x6b, 0x5d, 0xd9, 0x19, 0xb7,
+	0x06, 0x13, 0x29, 0x27, 0x11, 0x1b, 0xe4, 0xff, 0xee, 0x06, 0xc7, 0xe6, 0x0f, 0xdd, 0xe2, 0x42,
+	0xab, 0x74, 0x6f, 0x83, 0x1c, 0x02, 0x39, 0x61, 0xfa, 0x19, 0x57, 0x58, 0x69, 0x2f, 0xb3, 0x3e,
+	0x29, 0x73, 0xb3, 0x93, 0x2f, 0x8c, 0xc5, 0xee, 0xf1, 0x36, 0xc8, 0x53, 0xe8, 0xd8, 0x05, 0x93,
+	0x9f, 0xbe, 0xce, 0xea, 0x95, 0x38, 0xf4, 0x36, 0xc8, 0x47, 0x8b, 0xd3, 0x76, 0xf9, 0x90, 0xbd,
+	0x95, 0xa5, 0x94, 0xed, 0xae, 0x72, 0x07, 0xc3, 0xdf, 0x6b, 0x50, 0xc7, 0x2a, 0x21, 0x8f, 0xa0,
+	0x69, 0xb2, 0x6e, 0xeb, 0xa9, 0xec, 0x09, 0x2b, 0x5d, 0x6a, 0xb9, 0xde, 0x06, 0x79, 0x02, 0x70,
+	0xc2, 0x74, 0xde, 0xda, 0x65, 0x67, 0x77, 0xaf, 0x77, 0xb8, 0x39, 0x7c, 0x0c, 0x37, 0x4c, 0x26,
+	0x8a, 0x4d, 0x52, 0xe6, 0xc1, 0x2d, 0x69, 0x13, 0xe3, 0xe6, 0x14, 0x76, 0x28, 0x33, 0x8d, 0x59,
+	0xac, 0xe4, 0x32, 0x47, 0x77, 0x8a, 0x8e, 0xae, 0x75, 0x13, 0x5e, 0x0a, 0x6c, 0x81, 0xfd, 0xbb,
+	0xe2, 0x7f, 0x0e, 0xdb, 0x59, 0xf7, 0xe5, 0xc1, 0xe9, 0x15, 0xbf, 0xbc, 0xda, 0x99, 0x7f, 0x91,
+	0xe6, 0xa7, 0xd0, 0x3d, 0x63, 0x59, 0x85, 0x1c, 0xdb, 0xb1, 0x46, 0xd6, 0x2c, 0xc6, 0xde, 0x1a,
+	0xcc, 0xdb, 0x20, 0x8f, 0xa0, 0xfe, 0xcc, 0x2c, 0xaf, 0xd2, 0x70, 0x94, 0x7f, 0xf8, 0x21, 0xd4,
+	0xce, 0xb4, 0x8c, 0xff, 0xf9, 0xc9, 0xf1, 0x26, 0x22, 0x0f, 0xfe, 0x1c, 0x00, 0x51, 0x81, 0x14,
+	0x7a, 0x50, 0x0d, 0x00, 0x00,
 }
@@ -0,0 +1,268 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"diato/config"
+	pb "diato/pb"
+	"diato/util/stop"
+
+	empty "github.com/golang/protobuf/ptypes/empty"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+)
+
+// Clients of the admin API must present the configured
+// token in the metadata of each call, using this key.
+const AdminTokenKey = "diato-admin-token"
+
+func (s *Server) startAdmin(config *config.AdminConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	ln, err := net.Listen("unix", config.SocketPath)
+	if err != nil {
+		return fmt.Errorf("Could not listen on admin socket: %s", err.Error())
+	}
+
+	// The token is what grants access, but there's no
+	// need for anyone else to even try.
+	if err := os.Chmod(config.SocketPath, 0600); err != nil {
+		ln.Close()
+		return fmt.Errorf("Could not set permissions of admin socket: %s", err.Error())
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.UnaryInterceptor(adminAuthInterceptor(config.Token)),
+	)
+	pb.RegisterAdminServer(grpcServer, &rpcAdminServer{s})
+	for _, m := range s.modules.modules {
+		if adminModule, ok := m.(AdminModule); ok {
+			adminModule.RegisterAdminEndpoints(grpcServer)
+		}
+	}
+
+	stopper := stop.NewStopper(func() {
+		ln.Close()
+	})
+
+	go func() {
+		err := grpcServer.Serve(ln)
+		if !stopper.IsStopping() {
+			log.Printf("Admin API stopped unexpectedly: %v", err)
+		}
+	}()
+
+	log.Printf("Admin API listening on %s", config.SocketPath)
+	return nil
+}
+
+func adminAuthInterceptor(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, ok := metadata.FromIncomingContext(ctx)
+		if !ok || len(md[AdminTokenKey]) != 1 {
+			return nil, grpc.Errorf(codes.Unauthenticated, "No admin token was presented")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(md[AdminTokenKey][0]), []byte(token)) != 1 {
+			log.Printf("Rejected call to %s with an invalid admin token", info.FullMethod)
+			return nil, grpc.Errorf(codes.PermissionDenied, "Invalid admin token")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+type rpcAdminServer struct {
+	diato *Server
+}
+
+func (s *rpcAdminServer) GetStatus(ctx context.Context, _ *empty.Empty) (*pb.AdminStatus, error) {
+	status := &pb.AdminStatus{
+		Pid:             int32(os.Getpid()),
+		StartedAt:       s.diato.startedAt.Unix(),
+		Listeners:       make([]*pb.AdminListener, 0, len(s.diato.httpBind)),
+		Workers:         s.getWorkers(),
+		Modules:         s.getModules(),
+		UserBackendSize: uint32(s.diato.userBackend.Size()),
+		Draining:        s.diato.isDraining(),
+	}
+
+	for _, bind := range s.diato.httpBind {
+		status.Listeners = append(status.Listeners, &pb.AdminListener{
+			Name:          bind.name,
+			Bind:          bind.listen,
+			Tls:           bind.hasSsl,
+			ProxyProtocol: bind.proxyProto,
+		})
+	}
+
+	if s.diato.tlsCertStore != nil {
+		status.CertificateCount = uint32(s.diato.tlsCertStore.NumberOfCerts())
+	}
+
+	return status, nil
+}
+
+func (s *rpcAdminServer) GetWorkers(ctx context.Context, _ *empty.Empty) (*pb.AdminWorkers, error) {
+	return &pb.AdminWorkers{Workers: s.getWorkers()}, nil
+}
+
+func (s *rpcAdminServer) GetCertificates(ctx context.Context, _ *empty.Empty) (*pb.AdminCertificates, error) {
+	res := &pb.AdminCertificates{
+		Certificates: make([]*pb.AdminCertificate, 0),
+	}
+	if s.diato.tlsCertStore == nil {
+		return res, nil
+	}
+
+	for _, cert := range s.diato.tlsCertStore.Certificates() {
+		res.Certificates = append(res.Certificates, &pb.AdminCertificate{
+			Path:     cert.path,
+			Names:    cert.names,
+			LoadedAt: cert.loadedAt.Unix(),
+			NotAfter: cert.notAfter.Unix(),
+		})
+	}
+
+	return res, nil
+}
+
+func (s *rpcAdminServer) ReloadUserBackend(ctx context.Context, _ *empty.Empty) (*pb.AdminUserBackendStatus, error) {
+	log.Print("Reloading user backend as requested through the admin API")
+	if err := s.diato.userBackend.Reload(); err != nil {
+		return nil, fmt.Errorf("Could not reload user backend: %s", err.Error())
+	}
+
+	return &pb.AdminUserBackendStatus{Size: uint32(s.diato.userBackend.Size())}, nil
+}
+
+func (s *rpcAdminServer) LookupUser(ctx context.Context, in *pb.UserBackendRequest) (*pb.UserBackendResponse, error) {
+	path := in.Path
+	if path == "" {
+		path = "/"
+	}
+
+	route, err := s.diato.userBackend.GetRouteForUser(in.Name, path, in.Headers)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UserBackendResponse{
+		Server:      route.Server,
+		Port:        route.Port,
+		Passthrough: s.diato.userBackend.IsPassthrough(in.Name),
+		StripPrefix: route.StripPrefix,
+		User:        route.User,
+	}, nil
+}
+
+func (s *rpcAdminServer) RecycleWorkers(ctx context.Context, in *pb.AdminRecycleRequest) (*empty.Empty, error) {
+	ids := make([]int, 0, len(in.Ids))
+	for _, id := range in.Ids {
+		if s.diato.workers.get(int(id)) == nil {
+			return nil, fmt.Errorf("Unknown worker %d", id)
+		}
+		ids = append(ids, int(id))
+	}
+
+	if len(ids) == 0 {
+		ids = s.diato.workers.ids()
+	}
+
+	go s.diato.recycleWorkers(ids)
+	return &empty.Empty{}, nil
+}
+
+func (s *rpcAdminServer) SetModuleEnabled(ctx context.Context, in *pb.AdminModule) (*pb.AdminModule, error) {
+	known := false
+	for _, name := range s.diato.modules.names(s.diato.workers) {
+		if name == in.Name {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("Unknown module '%s'", in.Name)
+	}
+
+	if s.diato.modules.isEnabled(in.Name) != in.Enabled {
+		log.Printf("Setting module '%s' to enabled=%t as requested through the admin API", in.Name, in.Enabled)
+		s.diato.modules.setEnabled(in.Name, in.Enabled)
+		go s.diato.recycleWorkers(s.diato.workers.ids())
+	}
+
+	return &pb.AdminModule{Name: in.Name, Enabled: in.Enabled}, nil
+}
+
+func (s *rpcAdminServer) Drain(ctx context.Context, _ *empty.Empty) (*empty.Empty, error) {
+	log.Print("Draining as requested through the admin API")
+	s.diato.drain()
+
+	return &empty.Empty{}, nil
+}
+
+func (s *rpcAdminServer) Stop(ctx context.Context, _ *empty.Empty) (*empty.Empty, error) {
+	log.Print("Stopping as requested through the admin API")
+
+	// Give the response a chance to reach the client
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		stop.Stop()
+	}()
+
+	return &empty.Empty{}, nil
+}
+
+func (s *rpcAdminServer) getWorkers() []*pb.AdminWorker {
+	workers := make([]*pb.AdminWorker, 0)
+	for _, worker := range s.diato.workers.all() {
+		workers = append(workers, &pb.AdminWorker{
+			Id:          uint32(worker.id),
+			Pid:         int32(worker.process.Pid),
+			StartedAt:   worker.startedAt.Unix(),
+			Restarts:    worker.restarts,
+			Modules:     worker.getModules(),
+			Connections: atomic.LoadInt64(&worker.conns),
+			Healthy:     worker.isHealthy(),
+		})
+	}
+
+	return workers
+}
+
+func (s *rpcAdminServer) getModules() []*pb.AdminModule {
+	modules := make([]*pb.AdminModule, 0)
+	for _, name := range s.diato.modules.names(s.diato.workers) {
+		modules = append(modules, &pb.AdminModule{
+			Name:    name,
+			Enabled: s.diato.modules.isEnabled(name),
+		})
+	}
+
+	return modules
+}
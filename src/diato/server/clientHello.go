@@ -0,0 +1,183 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+)
+
+var errNotClientHello = errors.New("Connection did not start with a TLS ClientHello")
+
+// A ClientHello rarely exceeds a couple hundred bytes, but
+// may legitimately span multiple records.
+const maxClientHelloSize = 64 * 1024
+
+// peekClientHello reads the ClientHello the client starts its TLS
+// handshake with, and returns the server name it asked for. The returned
+// connection replays what was read, so the handshake can be completed
+// by us or by whatever the connection is passed on to.
+func peekClientHello(conn net.Conn) (string, net.Conn, error) {
+	peeked := &bytes.Buffer{}
+	r := io.TeeReader(conn, peeked)
+	replay := &peekedConn{conn, io.MultiReader(peeked, conn)}
+
+	handshake, err := readHandshake(r)
+	if err != nil {
+		return "", replay, err
+	}
+
+	serverName, err := parseClientHello(handshake)
+	return serverName, replay, err
+}
+
+// readHandshake reads the first handshake message from one or more
+// TLS records.
+func readHandshake(r io.Reader) ([]byte, error) {
+	msg := make([]byte, 0, 512)
+	header := make([]byte, 5)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, err
+		}
+		if header[0] != 0x16 || header[1] != 3 { // Handshake record, TLS 1.x
+			return nil, errNotClientHello
+		}
+
+		length := int(binary.BigEndian.Uint16(header[3:5]))
+		if len(msg)+length > maxClientHelloSize {
+			return nil, errors.New("ClientHello exceeds the maximum size")
+		}
+
+		record := make([]byte, length)
+		if _, err := io.ReadFull(r, record); err != nil {
+			return nil, err
+		}
+		msg = append(msg, record...)
+
+		if len(msg) < 4 {
+			continue
+		}
+		if msg[0] != 1 { // ClientHello
+			return nil, errNotClientHello
+		}
+
+		msgLength := 4 + (int(msg[1])<<16 | int(msg[2])<<8 | int(msg[3]))
+		if len(msg) >= msgLength {
+			return msg[4:msgLength], nil
+		}
+		if msgLength > maxClientHelloSize {
+			return nil, errors.New("ClientHello exceeds the maximum size")
+		}
+	}
+}
+
+// parseClientHello returns the server name from the server_name
+// extension, or an empty string if the client didn't send one.
+func parseClientHello(hello []byte) (string, error) {
+	errMalformed := errors.New("Malformed ClientHello")
+	p := &helloParser{data: hello}
+
+	p.skip(2 + 32)     // Version, random
+	p.skip(p.uint8())  // Session id
+	p.skip(p.uint16()) // Cipher suites
+	p.skip(p.uint8())  // Compression methods
+	if p.err {
+		return "", errMalformed
+	}
+	if len(p.data) == 0 {
+		return "", nil // No extensions at all
+	}
+
+	extensions := &helloParser{data: p.bytes(p.uint16())}
+	for !extensions.err && len(extensions.data) > 0 {
+		typ := extensions.uint16()
+		data := extensions.bytes(extensions.uint16())
+		if typ != 0 { // server_name
+			continue
+		}
+
+		list := &helloParser{data: data}
+		names := &helloParser{data: list.bytes(list.uint16())}
+		for !names.err && len(names.data) > 0 {
+			nameType := names.uint8()
+			name := names.bytes(names.uint16())
+			if nameType == 0 && !names.err { // host_name
+				return string(name), nil
+			}
+		}
+		if list.err || names.err {
+			return "", errMalformed
+		}
+	}
+	if extensions.err || p.err {
+		return "", errMalformed
+	}
+
+	return "", nil
+}
+
+// helloParser reads the fields of a ClientHello, remembering if it
+// ran out of data so the checking can be done once at the end.
+type helloParser struct {
+	data []byte
+	err  bool
+}
+
+func (p *helloParser) bytes(n int) []byte {
+	if p.err || len(p.data) < n {
+		p.err = true
+		return nil
+	}
+
+	res := p.data[:n]
+	p.data = p.data[n:]
+	return res
+}
+
+func (p *helloParser) skip(n int) {
+	p.bytes(n)
+}
+
+func (p *helloParser) uint8() int {
+	b := p.bytes(1)
+	if b == nil {
+		return 0
+	}
+	return int(b[0])
+}
+
+func (p *helloParser) uint16() int {
+	b := p.bytes(2)
+	if b == nil {
+		return 0
+	}
+	return int(binary.BigEndian.Uint16(b))
+}
+
+// peekedConn first replays what was peeked, before
+// reading from the connection itself.
+type peekedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *peekedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
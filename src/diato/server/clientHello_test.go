@@ -0,0 +1,166 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestParseClientHello(t *testing.T) {
+	tests := []struct {
+		name    string
+		hello   []byte
+		want    string
+		wantErr bool
+	}{
+		{"no extensions", testClientHello(nil), "", false},
+		{"server name", testClientHello(testServerName(0, "example.com")), "example.com", false},
+		{
+			"other extension first",
+			testClientHello(append(testExtension(16, []byte{0, 3, 2, 'h', '2'}), testServerName(0, "example.com")...)),
+			"example.com", false,
+		},
+		{"no server name", testClientHello(testExtension(16, []byte{0, 3, 2, 'h', '2'})), "", false},
+		{"other name type first", testClientHello(testServerName(1, "other", 0, "example.com")), "example.com", false},
+		{"only other name types", testClientHello(testServerName(1, "other")), "", false},
+		{"empty", []byte{}, "", true},
+		{"truncated", testClientHello(nil)[:20], "", true},
+		{"truncated server name", testClientHello(testServerName(0, "example.com"))[:60], "", true},
+		{"extensions exceed hello", append(testClientHello(nil), 0, 8, 0, 0), "", true},
+		{
+			"name exceeds list",
+			testClientHello(testExtension(0, []byte{0, 4, 0, 0, 9, 'a'})),
+			"", true,
+		},
+	}
+
+	for _, test := range tests {
+		got, err := parseClientHello(test.hello)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("%s: got server name '%s', want '%s'", test.name, got, test.want)
+		}
+	}
+}
+
+func TestReadHandshake(t *testing.T) {
+	hello := testClientHello(testServerName(0, "example.com"))
+	msg := append([]byte{1, 0, 0, byte(len(hello))}, hello...)
+
+	tests := []struct {
+		name    string
+		input   []byte
+		wantErr bool
+	}{
+		{"one record", testRecord(msg), false},
+		{"split over records", append(testRecord(msg[:10]), testRecord(msg[10:])...), false},
+		{"trailing data", append(testRecord(msg), "more"...), false},
+		{"plain http", []byte("GET / HTTP/1.1\r\nHost: example.com\r\n\r\n"), true},
+		{"not a client hello", testRecord(append([]byte{2}, msg[1:]...)), true},
+		{"truncated record", testRecord(msg)[:20], true},
+	}
+
+	for _, test := range tests {
+		got, err := readHandshake(bytes.NewReader(test.input))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %t", test.name, err, test.wantErr)
+		}
+		if err == nil && !bytes.Equal(got, hello) {
+			t.Errorf("%s: got handshake %x, want %x", test.name, got, hello)
+		}
+	}
+}
+
+func TestPeekClientHello(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	go func() {
+		conf := &tls.Config{ServerName: "example.com", InsecureSkipVerify: true}
+		tls.Client(client, conf).Handshake()
+		client.Close()
+	}()
+
+	serverName, replay, err := peekClientHello(server)
+	if err != nil {
+		t.Fatalf("Could not peek ClientHello: %s", err.Error())
+	}
+	if serverName != "example.com" {
+		t.Errorf("Got server name '%s', want 'example.com'", serverName)
+	}
+
+	// What was peeked is replayed
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(replay, header); err != nil {
+		t.Fatalf("Could not read from replayed connection: %s", err.Error())
+	}
+	if header[0] != 0x16 || header[1] != 3 {
+		t.Errorf("Replayed connection starts with %x, want a handshake record", header)
+	}
+}
+
+// testClientHello returns the body of a ClientHello handshake message,
+// with the given extensions if they're not nil.
+func testClientHello(extensions []byte) []byte {
+	hello := []byte{3, 3}                      // Version
+	hello = append(hello, make([]byte, 32)...) // Random
+	hello = append(hello, 0)                   // Session id
+	hello = append(hello, 0, 2, 0x13, 0x01)    // Cipher suites
+	hello = append(hello, 1, 0)                // Compression methods
+	if extensions != nil {
+		hello = append(hello, testUint16(len(extensions))...)
+		hello = append(hello, extensions...)
+	}
+
+	return hello
+}
+
+func testExtension(typ int, data []byte) []byte {
+	ext := append(testUint16(typ), testUint16(len(data))...)
+	return append(ext, data...)
+}
+
+// testServerName returns a server_name extension, which holds
+// the given pairs of name types and names.
+func testServerName(pairs ...interface{}) []byte {
+	list := make([]byte, 0)
+	for i := 0; i < len(pairs); i += 2 {
+		name := pairs[i+1].(string)
+		list = append(list, byte(pairs[i].(int)))
+		list = append(list, testUint16(len(name))...)
+		list = append(list, name...)
+	}
+
+	return testExtension(0, append(testUint16(len(list)), list...))
+}
+
+func testRecord(fragment []byte) []byte {
+	record := append([]byte{0x16, 3, 1}, testUint16(len(fragment))...)
+	return append(record, fragment...)
+}
+
+func testUint16(n int) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, uint16(n))
+	return b
+}
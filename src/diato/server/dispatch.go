@@ -0,0 +1,97 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"hash/fnv"
+	"strconv"
+	"sync/atomic"
+)
+
+// The ways of picking a worker for a connection, see [diato] worker-dispatch
+const (
+	dispatchLeastConnections = "least-connections"
+	dispatchClientIpHash     = "client-ip-hash"
+)
+
+// dispatch picks the worker to hand a connection to. Workers that are
+// recycling or unhealthy are skipped, unless there's no other option.
+// Workers in exclude were tried already and are skipped regardless.
+func (s *Server) dispatch(clientIp string, exclude map[int]bool) *workerProcess {
+	candidates := make([]*workerProcess, 0)
+	fallback := make([]*workerProcess, 0)
+	for _, worker := range s.workers.all() {
+		if exclude[worker.id] {
+			continue
+		}
+
+		if worker.isRecycling() || !worker.isHealthy() {
+			fallback = append(fallback, worker)
+			continue
+		}
+		candidates = append(candidates, worker)
+	}
+
+	if len(candidates) == 0 {
+		candidates = fallback
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if s.workerDispatch == dispatchClientIpHash {
+		return dispatchByHash(candidates, clientIp)
+	}
+
+	return s.dispatchByConnections(candidates)
+}
+
+// dispatchByConnections picks the worker with the fewest connections.
+// Ties are broken round robin, so an idle daemon spreads its load too.
+func (s *Server) dispatchByConnections(candidates []*workerProcess) *workerProcess {
+	offset := int(atomic.AddUint32(&s.dispatchCounter, 1))
+
+	var best *workerProcess
+	var bestConns int64
+	for i := range candidates {
+		worker := candidates[(offset+i)%len(candidates)]
+		conns := atomic.LoadInt64(&worker.conns)
+		if best == nil || conns < bestConns {
+			best, bestConns = worker, conns
+		}
+	}
+
+	return best
+}
+
+// dispatchByHash picks a worker based on the client's IP using rendezvous
+// hashing. Clients stick to the same worker, and only the clients of a
+// worker that becomes unavailable are moved to another one.
+func dispatchByHash(candidates []*workerProcess, clientIp string) *workerProcess {
+	var best *workerProcess
+	var bestScore uint64
+	for _, worker := range candidates {
+		h := fnv.New64a()
+		h.Write([]byte(clientIp))
+		h.Write([]byte(strconv.Itoa(worker.id)))
+
+		if score := h.Sum64(); best == nil || score > bestScore {
+			best, bestScore = worker, score
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,131 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"diato/metrics"
+	"diato/util/stop"
+)
+
+// The Host of the requests the server uses to check if a worker is still
+// serving requests. It's not a valid hostname, so it can't collide with
+// any actual site. Keep in sync with healthCheckHost in worker/health.go.
+const healthCheckHost = "_diato-health"
+
+const (
+	healthCheckInterval = 5 * time.Second
+	healthCheckTimeout  = 2 * time.Second
+
+	// Consecutive failed checks before a worker is considered unhealthy
+	healthCheckThreshold = 2
+)
+
+func (s *Server) healthCheckLoop() {
+	ticker := time.NewTicker(healthCheckInterval)
+	stopper := stop.NewStopper(func() {
+		ticker.Stop()
+	})
+
+	failures := make(map[*workerProcess]int)
+	for {
+		select {
+		case <-ticker.C:
+		case <-stopper.ShouldStop():
+			return
+		}
+
+		workers := s.workers.all()
+		results := make([]error, len(workers))
+		wg := &sync.WaitGroup{}
+		for i, worker := range workers {
+			if worker.isRecycling() {
+				continue
+			}
+
+			wg.Add(1)
+			go func(i int, worker *workerProcess) {
+				results[i] = healthCheck(worker)
+				wg.Done()
+			}(i, worker)
+		}
+		wg.Wait()
+
+		seen := make(map[*workerProcess]int)
+		for i, worker := range workers {
+			seen[worker] = failures[worker]
+			if worker.isRecycling() {
+				continue
+			}
+
+			if results[i] == nil {
+				if !worker.isHealthy() {
+					log.Printf("Worker %d passed its health check again", worker.id)
+				}
+				seen[worker] = 0
+				worker.setHealthy(true)
+				continue
+			}
+
+			metrics.WorkerHealthCheckFailures.Inc(strconv.Itoa(worker.id))
+			if seen[worker]++; seen[worker] == healthCheckThreshold {
+				log.Printf("Worker %d failed its health check, no longer dispatching to it: %s",
+					worker.id, results[i].Error())
+				worker.setHealthy(false)
+			}
+		}
+
+		// Forget about the workers that were restarted
+		failures = seen
+	}
+}
+
+// healthCheck makes a request through the worker's http socket,
+// which the worker answers itself.
+func healthCheck(worker *workerProcess) error {
+	conn, err := net.DialTimeout("unix", worker.sockets.httpPath, healthCheckTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(healthCheckTimeout))
+
+	_, err = fmt.Fprintf(conn, "PROXY TCP4 127.0.0.1 127.0.0.1 0 0\r\n"+
+		"GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", healthCheckHost)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -16,13 +16,19 @@
 package server
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
-	"io"
 	"log"
 	"net"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"diato/config"
+	"diato/metrics"
 	"diato/util/stop"
 
 	"github.com/Freeaqingme/go-proxyproto"
@@ -33,6 +39,20 @@ type httpBind struct {
 	listen     string
 	proxyProto bool
 	hasSsl     bool
+	limits     *config.ListenLimits
+
+	// With passthrough, the TLS handshake is done in handleConn
+	// rather than by the listener, see passthrough()
+	tlsPassthrough           bool
+	passthroughProxyProtocol bool
+	tlsConfig                *tls.Config
+
+	ln       net.Listener
+	draining int32
+
+	conns        int64
+	connsPerIp   map[string]int
+	connsPerIpMu sync.Mutex
 }
 
 func (s *Server) Listen(bind *httpBind) error {
@@ -47,7 +67,12 @@ func (s *Server) Listen(bind *httpBind) error {
 		logMsgSuffix = append(logMsgSuffix, "Proxy Protocol")
 	}
 
-	if bind.hasSsl {
+	if bind.hasSsl && bind.tlsPassthrough {
+		if bind.tlsConfig, err = s.tlsGetConfig(); err != nil {
+			return err
+		}
+		logMsgSuffix = append(logMsgSuffix, "TLS", "Passthrough")
+	} else if bind.hasSsl {
 		ln, err = s.tlsListen(ln)
 		if err != nil {
 			return err
@@ -62,56 +87,305 @@ func (s *Server) Listen(bind *httpBind) error {
 			bind.name, bind.listen, strings.Join(logMsgSuffix, ", "))
 	}
 
+	bind.ln = ln
 	stopper := stop.NewStopper(func() {
 		ln.Close()
 	})
 
-	go func() {
-		for {
-			conn, err := ln.Accept()
-			if err != nil {
-				if stopper.IsStopping() {
-					return
+	go s.acceptLoop(bind, ln, stopper, func(conn net.Conn) {
+		s.handleConn(bind, conn)
+	})
+	return nil
+}
+
+// acceptor is implemented by the http and stream listeners
+type acceptor interface {
+	getName() string
+	isDraining() bool
+	acquireConn() bool
+}
+
+// acceptLoop accepts connections until the listener is closed. Temporary
+// errors, like running out of file descriptors, are retried with an
+// increasing delay, the same way net/http does.
+func (s *Server) acceptLoop(bind acceptor, ln net.Listener, stopper *stop.Stopper, handle func(net.Conn)) {
+	name := bind.getName()
+	var delay time.Duration
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if stopper.IsStopping() || bind.isDraining() {
+				return
+			}
+
+			if netErr, ok := err.(net.Error); ok && netErr.Temporary() {
+				if delay == 0 {
+					delay = 5 * time.Millisecond
+				} else if delay *= 2; delay > time.Second {
+					delay = time.Second
 				}
-				panic(err.Error())
+
+				metrics.ConnectionErrors.Inc(name, "accept-temporary")
+				log.Printf("Could not accept connection on %s: %s; retrying in %s", name, err.Error(), delay)
+				time.Sleep(delay)
+				continue
 			}
-			go s.handleConn(bind, conn)
+
+			metrics.ConnectionErrors.Inc(name, "accept")
+			log.Printf("Error: No longer accepting connections on %s: %s", name, err.Error())
+			return
 		}
-	}()
+		delay = 0
 
-	return nil
+		if !bind.acquireConn() {
+			metrics.ConnectionsDropped.Inc(name, "max-connections")
+			conn.Close()
+			continue
+		}
+		go handle(conn)
+	}
+}
+
+func (bind *httpBind) getName() string {
+	return bind.name
+}
+
+// drain stops accepting new connections on this bind. Connections
+// that were already accepted are left alone.
+func (bind *httpBind) drain() {
+	if !atomic.CompareAndSwapInt32(&bind.draining, 0, 1) {
+		return
+	}
+
+	log.Printf("Draining %s: %s, no longer accepting new connections", bind.name, bind.listen)
+	bind.ln.Close()
+}
+
+func (bind *httpBind) isDraining() bool {
+	return atomic.LoadInt32(&bind.draining) == 1
+}
+
+// acquireConn tells if the listener can take another connection. If
+// so, releaseConn() must be called once the connection was closed.
+func (bind *httpBind) acquireConn() bool {
+	if atomic.AddInt64(&bind.conns, 1) > int64(bind.limits.MaxConnections) && bind.limits.MaxConnections != 0 {
+		atomic.AddInt64(&bind.conns, -1)
+		return false
+	}
+
+	return true
+}
+
+func (bind *httpBind) releaseConn() {
+	atomic.AddInt64(&bind.conns, -1)
+}
+
+// acquireIp tells if the client can open another connection. If
+// so, releaseIp() must be called once the connection was closed.
+func (bind *httpBind) acquireIp(ip string) bool {
+	if bind.limits.MaxConnectionsPerIp == 0 {
+		return true
+	}
+
+	bind.connsPerIpMu.Lock()
+	defer bind.connsPerIpMu.Unlock()
+
+	if bind.connsPerIp[ip] >= bind.limits.MaxConnectionsPerIp {
+		return false
+	}
+	bind.connsPerIp[ip]++
+	return true
+}
+
+func (bind *httpBind) releaseIp(ip string) {
+	if bind.limits.MaxConnectionsPerIp == 0 {
+		return
+	}
+
+	bind.connsPerIpMu.Lock()
+	defer bind.connsPerIpMu.Unlock()
+
+	if bind.connsPerIp[ip]--; bind.connsPerIp[ip] <= 0 {
+		delete(bind.connsPerIp, ip)
+	}
 }
 
 func (s *Server) handleConn(bind *httpBind, conn net.Conn) {
-	path := s.httpSocketPath
-	if bind.hasSsl {
-		path = s.httpsSocketPath
+	defer bind.releaseConn()
+	defer func() {
+		// A single connection should never take down the whole daemon
+		if r := recover(); r != nil {
+			metrics.ConnectionErrors.Inc(bind.name, "panic")
+			log.Printf("Error: Recovered from panic while handling connection on %s: %v\n%s",
+				bind.name, r, debug.Stack())
+			conn.Close()
+		}
+	}()
+
+	// Covers the PROXY header and TLS handshake as well. Once the
+	// request header is in, the splice switches to the idle timeout.
+	if bind.limits.HeaderReadTimeout != 0 {
+		conn.SetDeadline(time.Now().Add(bind.limits.HeaderReadTimeout))
 	}
 
-	client, err := net.Dial("unix", path)
-	if err != nil {
-		log.Fatalf("Dial failed: %v", err)
+	// With the PROXY protocol, this reads the header
+	ip, _, _ := net.SplitHostPort(conn.RemoteAddr().String())
+	if !bind.acquireIp(ip) {
+		metrics.ConnectionsDropped.Inc(bind.name, "max-connections-per-ip")
+		conn.Close()
+		return
+	}
+	defer bind.releaseIp(ip)
+
+	if bind.tlsPassthrough {
+		serverName, peeked, err := peekClientHello(conn)
+		conn = peeked
+		if err != nil {
+			metrics.ConnectionErrors.Inc(bind.name, "client-hello")
+			conn.Close()
+			return
+		}
+
+		if serverName = strings.ToLower(serverName); s.userBackend.IsPassthrough(serverName) {
+			s.passthrough(bind, conn, serverName)
+			return
+		}
+		conn = tls.Server(conn, bind.tlsConfig)
+	}
+
+	// Handshake explicitly rather than on first read, so we can tell
+	// failed handshakes apart from anything else going wrong later on.
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		if err := tlsConn.Handshake(); err != nil {
+			metrics.TlsHandshakes.Inc("error")
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				metrics.ConnectionsDropped.Inc(bind.name, "header-timeout")
+			}
+			conn.Close()
+			return
+		}
+		metrics.TlsHandshakes.Inc("ok")
 	}
 
 	hdr, err := s.getProxyProtoHeader(conn)
 	if err != nil {
-		panic("TODO, error handling: " + err.Error())
+		metrics.ConnectionErrors.Inc(bind.name, "proxy-header")
+		log.Printf("Could not determine PROXY header for connection on %s: %s", bind.name, err.Error())
+		conn.Close()
+		return
 	}
-	_, err = fmt.Fprint(client, hdr)
+
+	client, worker, err := s.dialWorker(bind, ip)
 	if err != nil {
-		panic("Todo: error handling")
+		metrics.ConnectionErrors.Inc(bind.name, "dial-worker")
+		log.Printf("Error: Could not connect to any worker for connection on %s: %s", bind.name, err.Error())
+		writeServiceUnavailable(conn)
+		conn.Close()
+		return
 	}
 
-	go func() {
-		defer client.Close()
-		defer conn.Close()
-		io.Copy(client, conn)
-	}()
-	go func() {
-		defer client.Close()
-		defer conn.Close()
-		io.Copy(conn, client)
-	}()
+	if _, err = fmt.Fprint(client, hdr); err != nil {
+		metrics.ConnectionErrors.Inc(bind.name, "write-proxy-header")
+		log.Printf("Could not send PROXY header to worker: %s", err.Error())
+		writeServiceUnavailable(conn)
+		client.Close()
+		conn.Close()
+		return
+	}
+
+	atomic.AddInt64(&worker.conns, 1)
+	defer atomic.AddInt64(&worker.conns, -1)
+
+	switch newSplice(conn, client, bind.limits).run() {
+	case errHeaderTooLarge:
+		metrics.ConnectionsDropped.Inc(bind.name, "header-too-large")
+	case errHeaderTimeout:
+		metrics.ConnectionsDropped.Inc(bind.name, "header-timeout")
+	}
+}
+
+// passthrough splices a TLS connection straight to the server of the
+// user, without terminating it. The ClientHello was peeked at only.
+func (s *Server) passthrough(bind *httpBind, conn net.Conn, serverName string) {
+	upstream, err := s.dialUserBackend(serverName, passthroughConnectTimeout)
+	if err != nil {
+		metrics.ConnectionErrors.Inc(bind.name, "dial-passthrough")
+		log.Printf("Could not connect to passthrough server for %s on %s: %s", serverName, bind.name, err.Error())
+		conn.Close()
+		return
+	}
+
+	if bind.passthroughProxyProtocol {
+		hdr, err := s.getProxyProtoHeader(conn)
+		if err == nil {
+			_, err = fmt.Fprint(upstream, hdr)
+		}
+		if err != nil {
+			metrics.ConnectionErrors.Inc(bind.name, "write-proxy-header")
+			log.Printf("Could not send PROXY header to passthrough server: %s", err.Error())
+			upstream.Close()
+			conn.Close()
+			return
+		}
+	}
+
+	metrics.TlsHandshakes.Inc("passthrough")
+	newStreamSplice(conn, upstream, bind.limits.IdleTimeout).run()
+}
+
+const passthroughConnectTimeout = 5 * time.Second
+
+// The attempts made to connect to a worker, and the delay between them
+const (
+	dialWorkerAttempts = 3
+	dialWorkerDelay    = 50 * time.Millisecond
+)
+
+// dialWorker connects to the worker picked by the dispatcher. When a worker
+// can't be reached it's marked unhealthy and the next attempt goes to
+// another one, so a single stuck worker doesn't fail the connection.
+func (s *Server) dialWorker(bind *httpBind, clientIp string) (net.Conn, *workerProcess, error) {
+	tried := make(map[int]bool)
+	err := errors.New("No workers available")
+	for attempt := 1; attempt <= dialWorkerAttempts; attempt++ {
+		worker := s.dispatch(clientIp, tried)
+		if worker == nil {
+			break
+		}
+		tried[worker.id] = true
+
+		path := worker.sockets.httpPath
+		if bind.hasSsl {
+			path = worker.sockets.httpsPath
+		}
+
+		var conn net.Conn
+		if conn, err = net.DialTimeout("unix", path, time.Second); err == nil {
+			return conn, worker, nil
+		}
+
+		if !worker.isRecycling() {
+			worker.setHealthy(false)
+		}
+		if attempt < dialWorkerAttempts {
+			metrics.ConnectionErrors.Inc(bind.name, "dial-worker-retry")
+			time.Sleep(time.Duration(attempt) * dialWorkerDelay)
+		}
+	}
+
+	return nil, nil, err
+}
+
+// writeServiceUnavailable lets the client know something's wrong on our
+// end, rather than just closing the connection. Nothing was sent to the
+// client yet at this point, so we can still respond.
+func writeServiceUnavailable(conn net.Conn) {
+	conn.SetWriteDeadline(time.Now().Add(time.Second))
+	fmt.Fprint(conn, "HTTP/1.1 503 Service Unavailable\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Length: 20\r\n"+
+		"Connection: close\r\n\r\n"+
+		"Service Unavailable\n")
 }
 
 // Originally derived from https://github.com/nabeken/mikoi
@@ -0,0 +1,137 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+
+	"diato/config"
+	"diato/metrics"
+	"diato/util/stop"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func (s *Server) startMetrics(config *config.MetricsConfig) error {
+	if !config.Enabled {
+		return nil
+	}
+
+	registry := prometheus.NewRegistry()
+	if err := s.metricsRegister(registry); err != nil {
+		return fmt.Errorf("Could not register metrics: %s", err.Error())
+	}
+
+	ln, err := net.Listen("tcp", config.Bind)
+	if err != nil {
+		return fmt.Errorf("Could not listen for metrics: %s", err.Error())
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(config.Path, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	srv := &http.Server{Handler: mux}
+
+	stopper := stop.NewStopper(func() {
+		ln.Close()
+	})
+
+	go func() {
+		err := srv.Serve(ln)
+		if !stopper.IsStopping() {
+			log.Printf("Metrics listener stopped unexpectedly: %v", err)
+		}
+	}()
+
+	log.Printf("Exposing metrics on http://%s%s", config.Bind, config.Path)
+	return nil
+}
+
+func (s *Server) metricsRegister(registry *prometheus.Registry) error {
+	collectors := []prometheus.Collector{
+		&metricsCollector{},
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "diato_user_backend_entries",
+			Help: "Number of entries in the user backend",
+		}, func() float64 {
+			return float64(s.userBackend.Size())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "diato_workers",
+			Help: "Number of workers currently running",
+		}, func() float64 {
+			return float64(len(s.workers.all()))
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "diato_tls_certificates",
+			Help: "Number of TLS certificates loaded",
+		}, func() float64 {
+			if s.tlsCertStore == nil {
+				return 0
+			}
+			return float64(s.tlsCertStore.NumberOfCerts())
+		}),
+	}
+
+	for _, collector := range collectors {
+		if err := registry.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// metricsCollector exposes the metrics of the server, which
+// includes the ones reported by the workers, to Prometheus.
+type metricsCollector struct{}
+
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range metrics.All() {
+		ch <- metricsDesc(m)
+	}
+}
+
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range metrics.All() {
+		desc := metricsDesc(m)
+		for _, series := range m.Snapshot() {
+			if !m.IsHistogram() {
+				ch <- prometheus.MustNewConstMetric(
+					desc, prometheus.CounterValue, series.Value, series.LabelValues...)
+				continue
+			}
+
+			// Prometheus expects the buckets to be cumulative
+			buckets := make(map[float64]uint64, len(m.Buckets))
+			var cumulative uint64
+			for i, upperBound := range m.Buckets {
+				cumulative += series.Buckets[i]
+				buckets[upperBound] = cumulative
+			}
+
+			ch <- prometheus.MustNewConstHistogram(
+				desc, series.Count, series.Sum, buckets, series.LabelValues...)
+		}
+	}
+}
+
+func metricsDesc(m *metrics.Metric) *prometheus.Desc {
+	return prometheus.NewDesc(m.Name, m.Help, m.Labels, nil)
+}
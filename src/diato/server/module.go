@@ -17,6 +17,8 @@ package server
 
 import (
 	"log"
+	"sort"
+	"sync"
 
 	"diato/config"
 
@@ -31,8 +33,20 @@ type Module interface {
 	RegisterRpcEndpoints(*grpc.Server)
 }
 
+// AdminModule is implemented by modules that offer calls through the
+// admin API, e.g. to purge a cache. Those are subject to the same token.
+type AdminModule interface {
+	Module
+	RegisterAdminEndpoints(*grpc.Server)
+}
+
 type moduleRegistry struct {
+	sync.RWMutex
 	modules []Module
+
+	// Modules that were toggled through the admin API. Workers
+	// skip the disabled ones upon start.
+	toggled map[string]bool
 }
 
 var moduleInitializers []func(*Server, *config.Config) ([]Module, error)
@@ -48,6 +62,7 @@ func RegisterModule(initializer func(*Server, *config.Config) ([]Module, error))
 func (s *Server) initModules(modules []func(*Server, *config.Config) ([]Module, error), config *config.Config) error {
 	registry := &moduleRegistry{
 		modules: make([]Module, 0),
+		toggled: make(map[string]bool),
 	}
 	for _, m := range modules {
 		initializedModules, err := m(s, config)
@@ -67,3 +82,60 @@ func (s *Server) initModules(modules []func(*Server, *config.Config) ([]Module,
 	s.modules = registry
 	return nil
 }
+
+func (r *moduleRegistry) setEnabled(name string, enabled bool) {
+	r.Lock()
+	defer r.Unlock()
+
+	r.toggled[name] = enabled
+}
+
+func (r *moduleRegistry) isEnabled(name string) bool {
+	r.RLock()
+	defer r.RUnlock()
+
+	enabled, toggled := r.toggled[name]
+	return !toggled || enabled
+}
+
+func (r *moduleRegistry) disabledNames() []string {
+	r.RLock()
+	defer r.RUnlock()
+
+	names := make([]string, 0)
+	for name, enabled := range r.toggled {
+		if !enabled {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// names returns the names of all modules loaded by either the
+// server or any of the workers, as well as those that were
+// disabled through the admin API.
+func (r *moduleRegistry) names(workers *workerRegistry) []string {
+	unique := make(map[string]struct{})
+	for _, m := range r.modules {
+		unique[m.Name()] = struct{}{}
+	}
+	for _, worker := range workers.all() {
+		for _, name := range worker.getModules() {
+			unique[name] = struct{}{}
+		}
+	}
+	r.RLock()
+	for name := range r.toggled {
+		unique[name] = struct{}{}
+	}
+	r.RUnlock()
+
+	names := make([]string, 0, len(unique))
+	for name := range unique {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
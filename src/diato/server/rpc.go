@@ -16,9 +16,12 @@
 package server
 
 import (
+	"fmt"
+	"hash/fnv"
 	"log"
 	"net"
 
+	"diato/metrics"
 	pb "diato/pb"
 	"diato/util/stop"
 
@@ -29,17 +32,19 @@ import (
 )
 
 func (s *Server) startRpc() error {
-	// Ideally this should be a socket too, but there appears
-	// to be no way to convert an FD into a net.conn object,
-	// like there is for listeners.
-	ln, err := net.Listen("tcp", "127.0.0.1:2938")
-	if err != nil {
-		log.Fatalf("failed to listen: %v", err)
-	}
+	// The RPC server does not listen on anything other processes could
+	// connect to. Each worker is handed one end of a socket pair, the
+	// other end is fed to the gRPC server once the worker has proven
+	// who it is (see rpcAcceptWorker()).
+	ln := newRpcListener()
+	s.rpcListener = ln
 
 	grpcServer := grpc.NewServer()
 	pb.RegisterUserBackendServer(grpcServer, &rpcUserBackendServer{s})
 	pb.RegisterServerServer(grpcServer, &rpcServerServer{s})
+	if s.geoIp != nil {
+		pb.RegisterGeoIpServer(grpcServer, &rpcGeoIpServer{s})
+	}
 	for _, module := range s.modules.modules {
 		module.RegisterRpcEndpoints(grpcServer)
 	}
@@ -70,11 +75,84 @@ func (s *rpcServerServer) GetConfigContents(ctx context.Context, _ *empty.Empty)
 	return &pb.ConfigContents{s.diato.configFileContents}, nil
 }
 
+func (s *rpcServerServer) GetDisabledModules(ctx context.Context, _ *empty.Empty) (*pb.ModuleList, error) {
+	return &pb.ModuleList{Names: s.diato.modules.disabledNames()}, nil
+}
+
+func (s *rpcServerServer) ReportModules(ctx context.Context, in *pb.ModuleList) (*empty.Empty, error) {
+	id, err := rpcGetWorkerId(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	worker := s.diato.workers.get(id)
+	if worker == nil {
+		return nil, fmt.Errorf("Unknown worker %d", id)
+	}
+	worker.setModules(in.Names)
+
+	return &empty.Empty{}, nil
+}
+
+func (s *rpcServerServer) ReportMetrics(ctx context.Context, in *pb.MetricsReport) (*empty.Empty, error) {
+	if _, err := rpcGetWorkerId(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := metrics.Merge(in); err != nil {
+		return nil, err
+	}
+
+	return &empty.Empty{}, nil
+}
+
+type rpcGeoIpServer struct {
+	diato *Server
+}
+
+func (s *rpcGeoIpServer) Lookup(ctx context.Context, in *pb.GeoIpRequest) (*pb.GeoIpResponse, error) {
+	ip := net.ParseIP(in.Ip)
+	if ip == nil {
+		return nil, fmt.Errorf("Invalid IP address '%s'", in.Ip)
+	}
+
+	return s.diato.geoIp.Lookup(ip)
+}
+
 type rpcUserBackendServer struct {
 	diato *Server
 }
 
 func (s *rpcUserBackendServer) GetServerForUser(ctx context.Context, in *pb.UserBackendRequest) (*pb.UserBackendResponse, error) {
-	host, port, err := s.diato.userBackend.GetServerForUser(in.Name)
-	return &pb.UserBackendResponse{host, port}, err
+	route, err := s.diato.userBackend.GetRouteForUser(in.Name, in.Path, in.Headers)
+	if err != nil {
+		return &pb.UserBackendResponse{}, err
+	}
+
+	return &pb.UserBackendResponse{
+		Server:      route.Server,
+		Port:        route.Port,
+		Passthrough: s.diato.userBackend.IsPassthrough(in.Name),
+		StripPrefix: route.StripPrefix,
+		User:        route.User,
+	}, nil
+}
+
+// GetRoutingHeaders returns the names of the headers requests are routed
+// by. Their version is derived from the names themselves, so it only
+// changes along with them.
+func (s *rpcUserBackendServer) GetRoutingHeaders(ctx context.Context, in *pb.RoutingHeadersRequest) (*pb.RoutingHeaders, error) {
+	names := s.diato.userBackend.HeaderNames()
+
+	hash := fnv.New64a()
+	for _, name := range names {
+		hash.Write([]byte(name + "\n"))
+	}
+	version := hash.Sum64()
+
+	if version == in.Version {
+		return &pb.RoutingHeaders{Version: version, Unchanged: true}, nil
+	}
+
+	return &pb.RoutingHeaders{Version: version, Names: names}, nil
 }
@@ -0,0 +1,199 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/peer"
+)
+
+// How long a freshly started worker gets to present its credentials
+const rpcAuthTimeout = 10 * time.Second
+
+// rpcListener is a net.Listener that yields the server side of the
+// socket pairs shared with the workers, so they can be served by a
+// regular grpc.Server.
+type rpcListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func newRpcListener() *rpcListener {
+	return &rpcListener{
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (l *rpcListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, errors.New("RPC listener was closed")
+	}
+}
+
+func (l *rpcListener) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.closed)
+	})
+	return nil
+}
+
+func (l *rpcListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: "[rpc]", Net: "unix"}
+}
+
+func (l *rpcListener) addConn(conn net.Conn) error {
+	select {
+	case l.conns <- conn:
+		return nil
+	case <-l.closed:
+		return errors.New("RPC listener was closed")
+	}
+}
+
+// rpcWorkerConn identifies the worker on the other end of the
+// connection, so RPC endpoints can tell which worker called them.
+type rpcWorkerConn struct {
+	*net.UnixConn
+	workerId int
+}
+
+func (c *rpcWorkerConn) RemoteAddr() net.Addr {
+	return rpcWorkerAddr(c.workerId)
+}
+
+type rpcWorkerAddr int
+
+func (a rpcWorkerAddr) Network() string {
+	return "unix"
+}
+
+func (a rpcWorkerAddr) String() string {
+	return fmt.Sprintf("worker-%d", int(a))
+}
+
+// rpcGetWorkerId returns the id of the worker that made the RPC call
+func rpcGetWorkerId(ctx context.Context) (int, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return 0, errors.New("Could not determine RPC peer")
+	}
+
+	addr, ok := p.Addr.(rpcWorkerAddr)
+	if !ok {
+		return 0, fmt.Errorf("RPC peer '%s' is not a worker", p.Addr)
+	}
+
+	return int(addr), nil
+}
+
+// Sets up a new socket pair to carry RPC calls between the server
+// and a single worker. The first return value is to be handed to the
+// worker, the second one is the server's end of the connection.
+//
+// SO_PASSCRED is enabled on the server's end before the worker is
+// even started, so the kernel attaches (and verifies) the credentials
+// of the worker on the first message it sends.
+func (s *Server) getNewRpcSocketPair() (*os.File, *net.UnixConn, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Could not create RPC socket pair: %s", err.Error())
+	}
+
+	serverFile := os.NewFile(uintptr(fds[0]), "[rpc-server]")
+	workerFile := os.NewFile(uintptr(fds[1]), "[rpc-worker]")
+	defer serverFile.Close() // net.FileConn() dup()s the fd
+
+	if err := syscall.SetsockoptInt(fds[0], syscall.SOL_SOCKET, syscall.SO_PASSCRED, 1); err != nil {
+		workerFile.Close()
+		return nil, nil, fmt.Errorf("Could not enable SO_PASSCRED on RPC socket: %s", err.Error())
+	}
+
+	conn, err := net.FileConn(serverFile)
+	if err != nil {
+		workerFile.Close()
+		return nil, nil, err
+	}
+
+	return workerFile, conn.(*net.UnixConn), nil
+}
+
+// rpcAcceptWorker waits for the worker with the given pid to present its
+// credentials on the server's end of its socket pair. Only once these
+// check out the connection is handed to the gRPC server.
+func (s *Server) rpcAcceptWorker(id, pid int, conn *net.UnixConn) {
+	if err := s.rpcAuthenticateWorker(pid, conn); err != nil {
+		log.Printf("Rejecting RPC connection of worker %d (pid %d): %s", id, pid, err.Error())
+		conn.Close()
+		return
+	}
+
+	if err := s.rpcListener.addConn(&rpcWorkerConn{conn, id}); err != nil {
+		conn.Close()
+	}
+}
+
+func (s *Server) rpcAuthenticateWorker(pid int, conn *net.UnixConn) error {
+	conn.SetReadDeadline(time.Now().Add(rpcAuthTimeout))
+	defer conn.SetReadDeadline(time.Time{})
+
+	// The worker sends a single byte with its credentials attached. We
+	// must not read any further, everything after it belongs to gRPC.
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(syscall.SizeofUcred))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		return fmt.Errorf("Could not read credentials: %s", err.Error())
+	}
+	if n != 1 || oobn == 0 {
+		return errors.New("No credentials were presented")
+	}
+
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil {
+		return fmt.Errorf("Could not parse credentials: %s", err.Error())
+	}
+	if len(msgs) != 1 {
+		return fmt.Errorf("Expected exactly 1 control message, got %d", len(msgs))
+	}
+
+	cred, err := syscall.ParseUnixCredentials(&msgs[0])
+	if err != nil {
+		return fmt.Errorf("Could not parse credentials: %s", err.Error())
+	}
+
+	if int(cred.Pid) != pid {
+		return fmt.Errorf("Peer has pid %d, expected %d", cred.Pid, pid)
+	}
+	if cred.Uid != WorkerUid || cred.Gid != WorkerGid {
+		return fmt.Errorf("Peer runs as %d:%d, expected %d:%d", cred.Uid, cred.Gid, WorkerUid, WorkerGid)
+	}
+
+	return nil
+}
@@ -18,8 +18,10 @@ package server
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"diato/config"
+	"diato/geoip"
 	"diato/userbackend"
 	"diato/userbackend/filemap"
 
@@ -29,17 +31,25 @@ import (
 
 type Server struct {
 	userBackend userbackend.Userbackend
+	geoIp       *geoip.Database
 
 	httpSocketPath  string
 	httpsSocketPath string
 	chrootPath      string
 	tlsCertDir      string
 
-	workerLimit uint
+	workerLimit    uint
+	workerDispatch string
+
+	// Used by the dispatcher to break ties between workers
+	dispatchCounter uint32
 
 	tlsCertStore   *tlsCertStore
 	curWorkerCount int32
 	modules        *moduleRegistry
+	rpcListener    *rpcListener
+	workers        *workerRegistry
+	startedAt      time.Time
 
 	// configFileContents contains the contents of the
 	// config file as it was read on start-up. This is
@@ -47,7 +57,8 @@ type Server struct {
 	// to the worker when requested.
 	configFileContents []byte
 
-	httpBind []httpBind
+	httpBind   []*httpBind
+	streamBind []*streamBind
 }
 
 func Start(configPath string) error {
@@ -66,6 +77,19 @@ func Start(configPath string) error {
 		return fmt.Errorf("Could ont initialize filemap userbackend: %s", err.Error())
 	}
 
+	if config.GeoIp.Enabled {
+		if s.geoIp, err = geoip.New(&config.GeoIp); err != nil {
+			return fmt.Errorf("Could not initialize GeoIP: %s", err.Error())
+		}
+	}
+
+	// Set up before the workers start, which may ask for certificates
+	if hasTls(config) {
+		if _, err := s.tlsGetConfig(); err != nil {
+			return err
+		}
+	}
+
 	if err := s.initModules(moduleInitializers, config); err != nil {
 		return err
 	}
@@ -79,21 +103,96 @@ func Start(configPath string) error {
 	}
 
 	for name, l := range config.Listen {
-		bind := httpBind{
+		limits, err := l.Limits()
+		if err != nil {
+			return err
+		}
+
+		bind := &httpBind{
 			name:       name,
 			listen:     l.Bind,
 			proxyProto: l.ProxyProtocol,
 			hasSsl:     l.TlsEnable,
+			limits:     limits,
+			connsPerIp: make(map[string]int),
+
+			tlsPassthrough:           l.TlsPassthrough,
+			passthroughProxyProtocol: l.PassthroughProxyProtocol,
 		}
 		s.httpBind = append(s.httpBind, bind)
-		if err := s.Listen(&bind); err != nil {
+		if err := s.Listen(bind); err != nil {
+			return err
+		}
+	}
+
+	for name, c := range config.Stream {
+		options, err := c.Options()
+		if err != nil {
 			return err
 		}
+
+		bind := &streamBind{
+			name:    name,
+			config:  c,
+			options: options,
+		}
+		s.streamBind = append(s.streamBind, bind)
+		if err := s.ListenStream(bind); err != nil {
+			return err
+		}
+	}
+
+	if err := s.startAdmin(&config.Admin); err != nil {
+		return err
+	}
+
+	if err := s.startMetrics(&config.Metrics); err != nil {
+		return err
 	}
 
 	return nil
 }
 
+func hasTls(conf *config.Config) bool {
+	for _, l := range conf.Listen {
+		if l.TlsEnable {
+			return true
+		}
+	}
+	for _, s := range conf.Stream {
+		if s.Mode == config.StreamModeTls {
+			return true
+		}
+	}
+
+	return false
+}
+
+// drain stops accepting new connections on all listeners
+func (s *Server) drain() {
+	for _, bind := range s.httpBind {
+		bind.drain()
+	}
+	for _, bind := range s.streamBind {
+		bind.drain()
+	}
+}
+
+func (s *Server) isDraining() bool {
+	for _, bind := range s.httpBind {
+		if !bind.isDraining() {
+			return false
+		}
+	}
+	for _, bind := range s.streamBind {
+		if !bind.isDraining() {
+			return false
+		}
+	}
+
+	return len(s.httpBind) > 0
+}
+
 func newServer(configPath string) (*Server, *config.Config, error) {
 	configFileContents, err := ioutil.ReadFile(configPath)
 	if err != nil {
@@ -115,7 +214,10 @@ func newServer(configPath string) (*Server, *config.Config, error) {
 		httpsSocketPath:    config.General.HttpsSocketPath,
 		chrootPath:         config.General.Chroot,
 		tlsCertDir:         config.General.TlsCertDir,
+		workerDispatch:     config.General.WorkerDispatch,
 		configFileContents: configFileContents,
+		workers:            newWorkerRegistry(),
+		startedAt:          time.Now(),
 	}
 	return s, config, nil
 }
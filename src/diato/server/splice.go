@@ -0,0 +1,195 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"time"
+
+	"diato/config"
+)
+
+var (
+	errHeaderTooLarge = errors.New("Request header exceeds max-header-size")
+	errHeaderTimeout  = errors.New("Request header was not received within header-read-timeout")
+)
+
+// The idle deadline is extended at most this often, so we don't
+// end up making syscalls for every single read.
+const idleDeadlineResolution = time.Second
+
+// splice copies data between a client and a worker, enforcing the
+// limits of the listener. Until the first request header was received
+// in full, the client has to make do with the header-read-timeout.
+// From then on, the connection is closed when idle for too long.
+type splice struct {
+	client net.Conn
+	worker net.Conn
+	limits *config.ListenLimits
+
+	headerDone     int32
+	headerBytes    int
+	headerTail     []byte
+	headerTooLarge bool
+
+	idleExtended int64 // UnixNano
+}
+
+func newSplice(client, worker net.Conn, limits *config.ListenLimits) *splice {
+	return &splice{
+		client: client,
+		worker: worker,
+		limits: limits,
+	}
+}
+
+// newStreamSplice splices a stream to its upstream. There's no request
+// header to wait for, so only the idle timeout applies from the start.
+func newStreamSplice(client, upstream net.Conn, idleTimeout time.Duration) *splice {
+	s := &splice{
+		client:     client,
+		worker:     upstream,
+		limits:     &config.ListenLimits{IdleTimeout: idleTimeout},
+		headerDone: 1,
+	}
+	s.extendIdleDeadline()
+
+	return s
+}
+
+// run blocks until either side closed the connection. An error is
+// only returned if the client did not send its header within limits.
+func (s *splice) run() error {
+	done := make(chan struct{})
+	go func() {
+		io.Copy(s.client, &spliceReader{s.worker, s.readWorker})
+		s.worker.Close()
+		s.client.Close()
+		close(done)
+	}()
+
+	_, err := io.Copy(s.worker, &spliceReader{s.client, s.readClient})
+	s.worker.Close()
+	s.client.Close()
+	<-done
+
+	// The error may have been wrapped by the worker connection
+	if s.headerTooLarge {
+		return errHeaderTooLarge
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() && atomic.LoadInt32(&s.headerDone) == 0 {
+		return errHeaderTimeout
+	}
+
+	return nil
+}
+
+func (s *splice) readClient(p []byte, n int) error {
+	if atomic.LoadInt32(&s.headerDone) == 1 {
+		s.extendIdleDeadline()
+		return nil
+	}
+
+	// Only what comes up to the end of the header counts towards its
+	// size, the rest of the read already belongs to the body.
+	buf := append(s.headerTail, p[:n]...)
+	end := headerEnd(buf)
+	if end != -1 {
+		n = end - len(s.headerTail)
+	}
+
+	s.headerBytes += n
+	if s.headerBytes > s.limits.MaxHeaderSize {
+		s.headerTooLarge = true
+		return errHeaderTooLarge
+	}
+
+	if end != -1 {
+		atomic.StoreInt32(&s.headerDone, 1)
+		s.extendIdleDeadline()
+		return nil
+	}
+
+	if len(buf) > 3 {
+		buf = buf[len(buf)-3:]
+	}
+	s.headerTail = append(s.headerTail[:0], buf...)
+	return nil
+}
+
+// headerEnd returns the offset right after the blank line that ends
+// the header in buf, or -1 if it's not in there. Go's net/http also
+// accepts headers ending in bare line feeds.
+func headerEnd(buf []byte) int {
+	end := -1
+	if i := bytes.Index(buf, []byte("\r\n\r\n")); i != -1 {
+		end = i + 4
+	}
+	if i := bytes.Index(buf, []byte("\n\n")); i != -1 && (end == -1 || i+2 < end) {
+		end = i + 2
+	}
+
+	return end
+}
+
+func (s *splice) readWorker(p []byte, n int) error {
+	if atomic.LoadInt32(&s.headerDone) == 1 {
+		s.extendIdleDeadline()
+	}
+
+	return nil
+}
+
+func (s *splice) extendIdleDeadline() {
+	now := time.Now()
+	last := atomic.LoadInt64(&s.idleExtended)
+	if now.UnixNano()-last < int64(idleDeadlineResolution) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&s.idleExtended, last, now.UnixNano()) {
+		return
+	}
+
+	var deadline time.Time
+	if s.limits.IdleTimeout != 0 {
+		deadline = now.Add(s.limits.IdleTimeout)
+	}
+
+	s.client.SetDeadline(deadline)
+	s.worker.SetDeadline(deadline)
+}
+
+// spliceReader calls back after every read, so the splice can keep
+// track of what's going on. An error from the callback aborts the read.
+type spliceReader struct {
+	r        io.Reader
+	callback func(p []byte, n int) error
+}
+
+func (r *spliceReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		if cbErr := r.callback(p, n); cbErr != nil {
+			return 0, cbErr
+		}
+	}
+
+	return n, err
+}
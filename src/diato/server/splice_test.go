@@ -0,0 +1,143 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"net"
+	"testing"
+
+	"diato/config"
+)
+
+func TestHeaderEnd(t *testing.T) {
+	tests := []struct {
+		buf  string
+		want int
+	}{
+		{"", -1},
+		{"GET / HTTP/1.1\r\nHost: a\r\n", -1},
+		{"GET / HTTP/1.1\r\nHost: a\r\n\r\n", 27},
+		{"GET / HTTP/1.1\r\nHost: a\r\n\r\nbody", 27},
+		{"GET / HTTP/1.1\nHost: a\n\nbody", 24},
+		{"\r\n\r\n", 4},
+		{"\n\n", 2},
+
+		// Whichever comes first ends the header
+		{"GET / HTTP/1.1\nHost: a\n\nbody\r\n\r\n", 24},
+		{"GET / HTTP/1.1\r\n\r\nbody\n\n", 18},
+		{"GET / HTTP/1.1\r\n\n", 17},
+	}
+
+	for _, test := range tests {
+		if got := headerEnd([]byte(test.buf)); got != test.want {
+			t.Errorf("headerEnd(%q) = %d, want %d", test.buf, got, test.want)
+		}
+	}
+}
+
+func TestSpliceReadClient(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxHeaderSize int
+		reads         []string
+		wantErr       error
+		wantDone      bool
+		wantBytes     int
+	}{
+		{
+			name:          "header in one read",
+			maxHeaderSize: 64,
+			reads:         []string{"GET / HTTP/1.1\r\nHost: a\r\n\r\n"},
+			wantDone:      true,
+			wantBytes:     27,
+		},
+		{
+			name:          "body does not count",
+			maxHeaderSize: 27,
+			reads:         []string{"GET / HTTP/1.1\r\nHost: a\r\n\r\n" + "0123456789"},
+			wantDone:      true,
+			wantBytes:     27,
+		},
+		{
+			name:          "header exceeds limit",
+			maxHeaderSize: 26,
+			reads:         []string{"GET / HTTP/1.1\r\nHost: a\r\n\r\n"},
+			wantErr:       errHeaderTooLarge,
+			wantBytes:     27,
+		},
+		{
+			name:          "header spread over reads",
+			maxHeaderSize: 64,
+			reads:         []string{"GET / HTTP/1.1\r\n", "Host: a\r\n", "\r\n"},
+			wantDone:      true,
+			wantBytes:     27,
+		},
+		{
+			name:          "blank line split across reads",
+			maxHeaderSize: 64,
+			reads:         []string{"GET / HTTP/1.1\r\nHost: a\r", "\n\r", "\nbody"},
+			wantDone:      true,
+			wantBytes:     27,
+		},
+		{
+			name:          "final read exceeds limit",
+			maxHeaderSize: 20,
+			reads:         []string{"GET / HTTP/1.1\r\n", "Host: a\r\n\r\nbody"},
+			wantErr:       errHeaderTooLarge,
+			wantBytes:     27,
+		},
+		{
+			name:          "bare line feeds",
+			maxHeaderSize: 64,
+			reads:         []string{"GET / HTTP/1.1\nHost: a\n", "\nbody"},
+			wantDone:      true,
+			wantBytes:     24,
+		},
+		{
+			name:          "incomplete header",
+			maxHeaderSize: 64,
+			reads:         []string{"GET / HTTP/1.1\r\n", "Host: a\r\n"},
+			wantBytes:     25,
+		},
+	}
+
+	for _, test := range tests {
+		client, worker := net.Pipe()
+		s := newSplice(client, worker, &config.ListenLimits{MaxHeaderSize: test.maxHeaderSize})
+
+		var err error
+		for _, read := range test.reads {
+			// The buffer is larger than the read, like it is with io.Copy
+			p := make([]byte, len(read)+32)
+			copy(p, read)
+			if err = s.readClient(p, len(read)); err != nil {
+				break
+			}
+		}
+		client.Close()
+		worker.Close()
+
+		if err != test.wantErr {
+			t.Errorf("%s: got error %v, want %v", test.name, err, test.wantErr)
+		}
+		if done := s.headerDone == 1; done != test.wantDone {
+			t.Errorf("%s: header done is %t, want %t", test.name, done, test.wantDone)
+		}
+		if s.headerBytes != test.wantBytes {
+			t.Errorf("%s: counted %d header bytes, want %d", test.name, s.headerBytes, test.wantBytes)
+		}
+	}
+}
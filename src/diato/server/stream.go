@@ -0,0 +1,200 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"diato/config"
+	"diato/metrics"
+	"diato/util/stop"
+
+	"github.com/Freeaqingme/go-proxyproto"
+)
+
+// The time a client gets to complete the TLS handshake, or
+// to send its ClientHello in passthrough mode.
+const streamHandshakeTimeout = 10 * time.Second
+
+// streamBind proxies arbitrary TCP streams straight to their upstream.
+// Unlike httpBind, the workers aren't involved.
+type streamBind struct {
+	name    string
+	config  *config.StreamConfig
+	options *config.StreamOptions
+
+	ln       net.Listener
+	draining int32
+	conns    int64
+}
+
+func (s *Server) ListenStream(bind *streamBind) error {
+	ln, err := net.Listen("tcp", bind.config.Bind)
+	if err != nil {
+		return err
+	}
+
+	logMsgSuffix := []string{bind.config.Mode}
+	if bind.config.ProxyProtocol {
+		ln = &proxyproto.Listener{Listener: ln}
+		logMsgSuffix = append(logMsgSuffix, "Proxy Protocol")
+	}
+
+	if bind.config.Mode == config.StreamModeTls {
+		if ln, err = s.tlsListen(ln); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Now streaming on %s: %s (%s)\n",
+		bind.name, bind.config.Bind, strings.Join(logMsgSuffix, ", "))
+
+	bind.ln = ln
+	stopper := stop.NewStopper(func() {
+		ln.Close()
+	})
+
+	go s.acceptLoop(bind, ln, stopper, func(conn net.Conn) {
+		s.handleStream(bind, conn)
+	})
+	return nil
+}
+
+func (bind *streamBind) getName() string {
+	return bind.name
+}
+
+// drain stops accepting new connections on this bind. Connections
+// that were already accepted are left alone.
+func (bind *streamBind) drain() {
+	if !atomic.CompareAndSwapInt32(&bind.draining, 0, 1) {
+		return
+	}
+
+	log.Printf("Draining %s: %s, no longer accepting new connections", bind.name, bind.config.Bind)
+	bind.ln.Close()
+}
+
+func (bind *streamBind) isDraining() bool {
+	return atomic.LoadInt32(&bind.draining) == 1
+}
+
+// acquireConn tells if the listener can take another connection. If
+// so, releaseConn() must be called once the connection was closed.
+func (bind *streamBind) acquireConn() bool {
+	if atomic.AddInt64(&bind.conns, 1) > int64(bind.options.MaxConnections) && bind.options.MaxConnections != 0 {
+		atomic.AddInt64(&bind.conns, -1)
+		return false
+	}
+
+	return true
+}
+
+func (bind *streamBind) releaseConn() {
+	atomic.AddInt64(&bind.conns, -1)
+}
+
+func (s *Server) handleStream(bind *streamBind, conn net.Conn) {
+	defer bind.releaseConn()
+	defer func() {
+		// A single connection should never take down the whole daemon
+		if r := recover(); r != nil {
+			metrics.ConnectionErrors.Inc(bind.name, "panic")
+			log.Printf("Error: Recovered from panic while handling connection on %s: %v\n%s",
+				bind.name, r, debug.Stack())
+			conn.Close()
+		}
+	}()
+
+	// With the PROXY protocol, the header is read as part of the handshake
+	conn.SetDeadline(time.Now().Add(streamHandshakeTimeout))
+
+	var serverName string
+	switch bind.config.Mode {
+	case config.StreamModeTls:
+		tlsConn := conn.(*tls.Conn)
+		if err := tlsConn.Handshake(); err != nil {
+			metrics.TlsHandshakes.Inc("error")
+			conn.Close()
+			return
+		}
+		metrics.TlsHandshakes.Inc("ok")
+		serverName = tlsConn.ConnectionState().ServerName
+
+	case config.StreamModePassthrough:
+		var err error
+		if serverName, conn, err = peekClientHello(conn); err != nil {
+			metrics.ConnectionErrors.Inc(bind.name, "client-hello")
+			conn.Close()
+			return
+		}
+	}
+
+	upstream, err := s.dialStreamUpstream(bind, serverName)
+	if err != nil {
+		metrics.ConnectionErrors.Inc(bind.name, "dial-upstream")
+		log.Printf("Could not connect to upstream for connection on %s: %s", bind.name, err.Error())
+		conn.Close()
+		return
+	}
+
+	if bind.config.SendProxyProtocol {
+		hdr, err := s.getProxyProtoHeader(conn)
+		if err == nil {
+			_, err = fmt.Fprint(upstream, hdr)
+		}
+		if err != nil {
+			metrics.ConnectionErrors.Inc(bind.name, "write-proxy-header")
+			log.Printf("Could not send PROXY header to upstream: %s", err.Error())
+			upstream.Close()
+			conn.Close()
+			return
+		}
+	}
+
+	newStreamSplice(conn, upstream, bind.options.IdleTimeout).run()
+}
+
+// dialStreamUpstream connects to the configured backend, or else to the
+// one the user backend has for the server name the client asked for.
+func (s *Server) dialStreamUpstream(bind *streamBind, serverName string) (net.Conn, error) {
+	if bind.config.Backend != "" {
+		return net.DialTimeout("tcp", bind.config.Backend, bind.options.ConnectTimeout)
+	}
+
+	if serverName == "" {
+		return nil, fmt.Errorf("Client did not indicate a server name")
+	}
+	return s.dialUserBackend(strings.ToLower(serverName), bind.options.ConnectTimeout)
+}
+
+// dialUserBackend connects to the server the user backend has for the given name
+func (s *Server) dialUserBackend(name string, timeout time.Duration) (net.Conn, error) {
+	host, port, err := s.userBackend.GetServerForUser(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))), timeout)
+}
@@ -23,10 +23,12 @@ import (
 	"net"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"diato/util/hostname"
 	"diato/util/stop"
 
 	"github.com/rjeczalik/notify"
@@ -45,6 +47,7 @@ type tlsCert struct {
 	tls.Certificate
 
 	loadedAt time.Time
+	notAfter time.Time
 	names    []string
 	path     string
 }
@@ -123,16 +126,24 @@ func (s *tlsCertStore) loadCertsFromFilesystem(searchDir string) error {
 // while the listener is already active - a use case Go's TLS library appears
 // not to have been designed for.
 func (s *tlsCertStore) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if cert := s.lookup(clientHello.ServerName); cert != nil {
+		return &cert.Certificate, nil
+	}
+
+	// If nothing matches, return nothing
+	return nil, nil
+	//return &s.certs[0], nil
+}
+
+// lookup returns the certificate for the given name, which
+// may be one for a wildcard. Returns nil if there's none.
+func (s *tlsCertStore) lookup(name string) *tlsCert {
 	s.RLock()
 	defer s.RUnlock()
 
-	name := strings.ToLower(clientHello.ServerName)
-	for len(name) > 0 && name[len(name)-1] == '.' {
-		name = name[:len(name)-1]
-	}
-
+	name = hostname.Normalize(name)
 	if cert, ok := s.nameToCert[name]; ok {
-		return &cert[0].Certificate, nil
+		return cert[0]
 	}
 
 	labels := strings.Split(name, ".")
@@ -140,13 +151,21 @@ func (s *tlsCertStore) getCertificate(clientHello *tls.ClientHelloInfo) (*tls.Ce
 		labels[i] = "*"
 		candidate := strings.Join(labels, ".")
 		if cert, ok := s.nameToCert[candidate]; ok {
-			return &cert[0].Certificate, nil
+			return cert[0]
 		}
 	}
 
-	// If nothing matches, return nothing
-	return nil, nil
-	//return &s.certs[0], nil
+	return nil
+}
+
+// HasCertificate tells if there's a certificate for the given
+// name, meaning clients can connect to it over TLS.
+func (s *Server) HasCertificate(name string) bool {
+	if s.tlsCertStore == nil {
+		return false // There are no TLS listeners
+	}
+
+	return s.tlsCertStore.lookup(name) != nil
 }
 
 func (s *tlsCertStore) NumberOfCerts() int {
@@ -163,6 +182,22 @@ func (s *tlsCertStore) NumberOfNames() int {
 	return len(s.nameToCert)
 }
 
+// Certificates returns all loaded certificates, ordered by path
+func (s *tlsCertStore) Certificates() []*tlsCert {
+	s.RLock()
+	defer s.RUnlock()
+
+	certs := make([]*tlsCert, 0, len(s.pathToCert))
+	for _, cert := range s.pathToCert {
+		certs = append(certs, cert)
+	}
+	sort.Slice(certs, func(i, j int) bool {
+		return certs[i].path < certs[j].path
+	})
+
+	return certs
+}
+
 func (s *tlsCertStore) watchForUpdates(path string) error {
 	c := make(chan notify.EventInfo, 1024)
 
@@ -229,6 +264,7 @@ func (s *tlsCertStore) loadCertFromFilesystem(path string) error {
 	decoratedCert := &tlsCert{
 		Certificate: cert,
 		loadedAt:    time.Now(),
+		notAfter:    x509Cert.NotAfter,
 		path:        path,
 	}
 
@@ -17,46 +17,204 @@ package server
 
 import (
 	"errors"
+	"fmt"
 	"log"
 	"net"
 	"os"
 	"os/exec"
+	"sort"
+	"strconv"
+	"sync"
 	"sync/atomic"
 	"syscall"
 	"time"
 
+	"diato/metrics"
 	"diato/util/stop"
 )
 
-func (s *Server) startWorkers(workerCount uint) error {
-	httpFd, err := s.getNewHttpSocket(false)
-	if err != nil {
-		return err
+// The uid and gid the worker drops its privileges to. Keep
+// in sync with dropUserPrivs() in worker/worker.c.
+const (
+	WorkerUid = 65534
+	WorkerGid = 65534
+)
+
+type workerRegistry struct {
+	sync.RWMutex
+	workers map[int]*workerProcess
+}
+
+type workerProcess struct {
+	// Number of client connections handed to the worker. First, as
+	// it's accessed atomically and must be 64-bit aligned on 32-bit.
+	conns int64
+
+	sync.Mutex
+
+	id        int
+	process   *os.Process
+	startedAt time.Time
+	restarts  uint32
+	recycling bool
+	sockets   *workerSockets
+
+	// Set while the worker fails its health checks
+	unhealthy int32
+
+	// The modules as reported by the worker
+	modules []string
+
+	// Closed once the process has exited
+	done chan struct{}
+}
+
+func newWorkerRegistry() *workerRegistry {
+	return &workerRegistry{
+		workers: make(map[int]*workerProcess),
+	}
+}
+
+// add registers a newly started process for the given worker id,
+// replacing its predecessor if there was any.
+func (r *workerRegistry) add(id int, process *os.Process, sockets *workerSockets) *workerProcess {
+	worker := &workerProcess{
+		id:        id,
+		process:   process,
+		startedAt: time.Now(),
+		sockets:   sockets,
+		done:      make(chan struct{}),
 	}
 
-	httpsFd, err := s.getNewHttpSocket(true)
-	if err != nil {
-		return err
+	r.Lock()
+	defer r.Unlock()
+	if prev, ok := r.workers[id]; ok {
+		worker.restarts = prev.restarts + 1
+	}
+	r.workers[id] = worker
+
+	return worker
+}
+
+func (r *workerRegistry) get(id int) *workerProcess {
+	r.RLock()
+	defer r.RUnlock()
+
+	return r.workers[id]
+}
+
+// all returns all workers, ordered by id
+func (r *workerRegistry) all() []*workerProcess {
+	r.RLock()
+	defer r.RUnlock()
+
+	workers := make([]*workerProcess, 0, len(r.workers))
+	for _, worker := range r.workers {
+		workers = append(workers, worker)
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].id < workers[j].id
+	})
+
+	return workers
+}
+
+func (r *workerRegistry) ids() []int {
+	ids := make([]int, 0)
+	for _, worker := range r.all() {
+		ids = append(ids, worker.id)
+	}
+
+	return ids
+}
+
+func (w *workerProcess) setModules(modules []string) {
+	w.Lock()
+	defer w.Unlock()
+
+	w.modules = modules
+}
+
+func (w *workerProcess) getModules() []string {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.modules
+}
+
+func (w *workerProcess) isRecycling() bool {
+	w.Lock()
+	defer w.Unlock()
+
+	return w.recycling
+}
+
+// recycle asks the worker to gracefully stop, after which it is
+// restarted. Returns false if the worker was already recycling.
+func (w *workerProcess) recycle() bool {
+	w.Lock()
+	defer w.Unlock()
+
+	if w.recycling {
+		return false
+	}
+	w.recycling = true
+	w.process.Signal(os.Interrupt)
+
+	return true
+}
+
+func (w *workerProcess) isHealthy() bool {
+	return atomic.LoadInt32(&w.unhealthy) == 0
+}
+
+func (w *workerProcess) setHealthy(healthy bool) {
+	if healthy {
+		atomic.StoreInt32(&w.unhealthy, 0)
+	} else {
+		atomic.StoreInt32(&w.unhealthy, 1)
 	}
+}
+
+// The sockets a worker accepts connections on. They outlive the worker
+// process, so connections queued up while it restarts aren't lost.
+type workerSockets struct {
+	httpFd    *os.File
+	httpsFd   *os.File
+	httpPath  string
+	httpsPath string
+}
 
+func (s *Server) startWorkers(workerCount uint) error {
 	throttle := time.Tick(1 * time.Second)
 	for i := 1; i <= int(workerCount); i++ {
-		if err := s.startWorker(i, httpFd, httpsFd, throttle); err != nil {
+		sockets, err := s.getWorkerSockets(i)
+		if err != nil {
+			return err
+		}
+
+		if err := s.startWorker(i, sockets, throttle); err != nil {
 			return err
 		}
 	}
 
+	go s.healthCheckLoop()
 	return nil
 }
 
-func (s *Server) startWorker(id int, httpFd *os.File, httpsFd *os.File, throttle <-chan time.Time) error {
+func (s *Server) startWorker(id int, sockets *workerSockets, throttle <-chan time.Time) error {
 	chrootFd, err := s.getChrootFd()
 	if err != nil {
 		return err
 	}
 
+	rpcFd, rpcConn, err := s.getNewRpcSocketPair()
+	if err != nil {
+		return err
+	}
+
 	cmd := exec.Command(os.Args[0], "internal-worker", "start")
-	cmd.ExtraFiles = []*os.File{chrootFd, httpFd, httpsFd}
+	cmd.ExtraFiles = []*os.File{chrootFd, sockets.httpFd, sockets.httpsFd, rpcFd}
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.SysProcAttr = &syscall.SysProcAttr{
@@ -64,10 +222,15 @@ func (s *Server) startWorker(id int, httpFd *os.File, httpsFd *os.File, throttle
 		Pdeathsig: syscall.SIGTERM,
 	}
 
-	if err := cmd.Start(); err != nil {
+	err = cmd.Start()
+	rpcFd.Close() // Only the worker should hold on to its end
+	if err != nil {
+		rpcConn.Close()
 		return err
 	}
 	atomic.AddInt32(&s.curWorkerCount, 1)
+	worker := s.workers.add(id, cmd.Process, sockets)
+	go s.rpcAcceptWorker(id, cmd.Process.Pid, rpcConn)
 
 	stopper := stop.NewStopper(func() {
 		cmd.Process.Signal(os.Interrupt)
@@ -76,26 +239,67 @@ func (s *Server) startWorker(id int, httpFd *os.File, httpsFd *os.File, throttle
 
 	go func() {
 		cmd.Process.Wait()
+		rpcConn.Close()
+		close(worker.done)
 		remainingWorkerCount := atomic.AddInt32(&s.curWorkerCount, -1)
 		if stopper.IsStopping() {
 			return
 		}
 
-		log.Printf("Worker %d died", id)
-		if remainingWorkerCount < 1 {
-			log.Println("No workers remaining, that can't be good. Stopping...")
-			stop.Stop()
-			return
+		if worker.isRecycling() {
+			log.Printf("Worker %d was recycled", id)
+		} else {
+			log.Printf("Worker %d died", id)
+			if remainingWorkerCount < 1 {
+				log.Println("No workers remaining, that can't be good. Stopping...")
+				stop.Stop()
+				return
+			}
 		}
 
 		<-throttle
 		log.Printf("Restarting worker %d...", id)
-		s.startWorker(id, httpFd, httpsFd, throttle)
+		metrics.WorkerRestarts.Inc(strconv.Itoa(id))
+		s.startWorker(id, sockets, throttle)
 	}()
 
 	return nil
 }
 
+// recycleWorkers gracefully restarts the given workers. This is done one
+// by one, so the remaining workers can keep on serving requests.
+func (s *Server) recycleWorkers(ids []int) {
+	for _, id := range ids {
+		worker := s.workers.get(id)
+		if worker == nil || !worker.recycle() {
+			continue
+		}
+
+		log.Printf("Recycling worker %d (pid %d)", id, worker.process.Pid)
+		<-worker.done
+	}
+}
+
+// getWorkerSockets sets up the http and https sockets of a worker.
+// Each worker has its own, so the server decides which worker
+// handles a connection rather than the kernel.
+func (s *Server) getWorkerSockets(id int) (*workerSockets, error) {
+	sockets := &workerSockets{
+		httpPath:  fmt.Sprintf("%s.%d", s.httpSocketPath, id),
+		httpsPath: fmt.Sprintf("%s.%d", s.httpsSocketPath, id),
+	}
+
+	var err error
+	if sockets.httpFd, err = s.getNewHttpSocket(sockets.httpPath); err != nil {
+		return nil, err
+	}
+	if sockets.httpsFd, err = s.getNewHttpSocket(sockets.httpsPath); err != nil {
+		return nil, err
+	}
+
+	return sockets, nil
+}
+
 // Sets up a new http socket. This socket is used to carry
 // plain-text http messages to the worker for further processing
 // Messages are supported by the proxy protocol (currently version
@@ -108,11 +312,8 @@ func (s *Server) startWorker(id int, httpFd *os.File, httpsFd *os.File, throttle
 // worker which is responsible for listening and accepting
 // connections on this socket. This ensures the worker can run
 // in a permission-less environment and does not require any IO.
-func (s *Server) getNewHttpSocket(tls bool) (*os.File, error) {
-	path := s.httpSocketPath
-	if tls {
-		path = s.httpsSocketPath
-	}
+// Every worker gets a socket of its own, see getWorkerSockets.
+func (s *Server) getNewHttpSocket(path string) (*os.File, error) {
 	listener, err := net.Listen("unix", path)
 	if err != nil {
 		return nil, err
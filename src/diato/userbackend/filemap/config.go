@@ -15,8 +15,41 @@
 // limitations under the License.
 package Filemap
 
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
 type Config struct {
 	Enabled    bool
 	Path       string
 	MinEntries int `gcfg:"min-entries"`
 }
+
+// Check validates the configuration without loading the map. All
+// problems found are returned, rather than just the first one.
+func (c *Config) Check() []error {
+	errs := make([]error, 0)
+	if !c.Enabled {
+		return errs
+	}
+
+	if c.Path == "" {
+		return append(errs, errors.New("No path was set"))
+	}
+
+	fileinfo, err := os.Stat(c.Path)
+	if err != nil {
+		return append(errs, fmt.Errorf("Could not open path '%s': %s", c.Path, err.Error()))
+	}
+	if fileinfo.IsDir() {
+		errs = append(errs, fmt.Errorf("Path '%s' is a directory, expected a file", c.Path))
+	}
+
+	if c.MinEntries < 0 {
+		errs = append(errs, fmt.Errorf("min-entries must not be negative, got %d", c.MinEntries))
+	}
+
+	return errs
+}
@@ -21,22 +21,72 @@ import (
 	"io/ioutil"
 	"log"
 	"net"
+	"net/textproto"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
+	"diato/userbackend"
+	"diato/util/hostname"
+	"diato/util/urlpath"
+
 	"github.com/rjeczalik/notify"
 )
 
 type Filemap struct {
 	sync.RWMutex
 
-	path       string
-	users      map[string]string
-	minEntries int
+	path        string
+	users       map[string][]*entry // Most specific route first
+	regexes     []*regexUser        // In the order of the file
+	headerNames []string            // Sorted
+	minEntries  int
 
 	watcher chan notify.EventInfo
 }
 
+// regexUser is a user that's matched by a regular expression,
+// rather than by its name. Its routes are found in users.
+type regexUser struct {
+	regex *regexp.Regexp
+	user  string
+}
+
+type entry struct {
+	server      string
+	passthrough bool
+
+	// If set, only requests that match are routed to the server
+	pathPrefix  string
+	header      string
+	headerValue string
+	stripPrefix bool
+}
+
+// isMoreSpecific tells if the entry should be considered
+// before the other. Header matches are, then longer prefixes.
+func (e *entry) isMoreSpecific(other *entry) bool {
+	if (e.header != "") != (other.header != "") {
+		return e.header != ""
+	}
+
+	return len(e.pathPrefix) > len(other.pathPrefix)
+}
+
+func (e *entry) isSameRoute(other *entry) bool {
+	return e.pathPrefix == other.pathPrefix && e.header == other.header && e.headerValue == other.headerValue
+}
+
+func (e *entry) matches(path string, header map[string]string) bool {
+	if e.header != "" && header[e.header] != e.headerValue {
+		return false
+	}
+
+	return urlpath.HasPrefix(path, e.pathPrefix)
+}
+
 func NewFilemap(path string, entriesRequired int) (*Filemap, error) {
 	res := &Filemap{
 		path:       path,
@@ -52,6 +102,17 @@ func NewFilemap(path string, entriesRequired int) (*Filemap, error) {
 	return res, err
 }
 
+func (f *Filemap) Size() int {
+	f.RLock()
+	defer f.RUnlock()
+
+	return len(f.users)
+}
+
+func (f *Filemap) Reload() error {
+	return f.update()
+}
+
 func (f *Filemap) update() error {
 	contents, err := ioutil.ReadFile(f.path)
 	if err != nil {
@@ -63,20 +124,68 @@ func (f *Filemap) update() error {
 func (f *Filemap) updateWithContents(contents []byte) error {
 	lines := bytes.Split(contents, []byte("\n"))
 
-	newMap := make(map[string]string)
+	newMap := make(map[string][]*entry)
+	newRegexes := make([]*regexUser, 0)
 	for i, line := range lines {
 		lineParts := bytes.Split(bytes.TrimSpace(line), []byte(" "))
 		if len(lineParts[0]) == 0 {
 			continue
 		}
 
+		// Regular expressions are prefixed by a '~' and kept as is. They're
+		// anchored, so '~example\.com' doesn't match example.com.evil.net.
 		user := string(lineParts[:1][0])
-		host := string(lineParts[len(lineParts)-1:][0])
+		if !strings.HasPrefix(user, "~") {
+			user = hostname.Normalize(user)
+		} else if _, seen := newMap[user]; !seen {
+			regex, err := regexp.Compile("^(?:" + user[1:] + ")$")
+			if err != nil {
+				log.Printf("Notice: Invalid regular expression '%s' on line %d, skipping it: %s",
+					user[1:], i, err.Error())
+				continue
+			}
+			newRegexes = append(newRegexes, &regexUser{regex, user})
+		}
+
+		e := &entry{server: string(lineParts[len(lineParts)-1:][0])}
 
-		if _, alreadyExists := newMap[user]; alreadyExists {
-			log.Printf("Notice: Domain %s was defined more than once on line %d", user, i)
+		// Any options are found between the user and the server
+		for j := 1; j < len(lineParts)-1; j++ {
+			option := string(lineParts[j])
+			switch {
+			case option == "":
+			case option == "passthrough":
+				e.passthrough = true
+			case option == "strip":
+				e.stripPrefix = true
+			case strings.HasPrefix(option, "path="):
+				e.pathPrefix = option[len("path="):]
+			case strings.HasPrefix(option, "header="):
+				nameValue := strings.SplitN(option[len("header="):], ":", 2)
+				if len(nameValue) != 2 || nameValue[0] == "" {
+					log.Printf("Notice: Invalid header '%s' for domain %s on line %d, expected name:value",
+						option[len("header="):], user, i)
+					continue
+				}
+				e.header, e.headerValue = textproto.CanonicalMIMEHeaderKey(nameValue[0]), nameValue[1]
+			default:
+				log.Printf("Notice: Unknown option '%s' for domain %s on line %d", option, user, i)
+			}
+		}
+
+		if e.pathPrefix != "" && e.pathPrefix[0] != '/' {
+			log.Printf("Notice: Path '%s' for domain %s on line %d must start with a '/', skipping it",
+				e.pathPrefix, user, i)
+			continue
+		}
+		if e.stripPrefix && e.pathPrefix == "" {
+			log.Printf("Notice: Domain %s on line %d should be stripped, but has no path", user, i)
+		}
+		if e.passthrough && (e.pathPrefix != "" || e.header != "") {
+			log.Printf("Notice: Domain %s on line %d is passed through, so its path or header is never looked at", user, i)
 		}
-		newMap[user] = host
+
+		newMap[user] = addRoute(newMap[user], e, user, i)
 	}
 
 	size := len(newMap)
@@ -88,29 +197,144 @@ func (f *Filemap) updateWithContents(contents []byte) error {
 	f.Lock()
 	defer f.Unlock()
 	f.users = newMap
+	f.regexes = newRegexes
+	f.headerNames = headerNames(newMap)
 	log.Printf("Loaded new user map. It now contains %d entries", size)
 
 	return nil
 }
 
+// headerNames returns the names of the headers routes match on
+func headerNames(users map[string][]*entry) []string {
+	seen := make(map[string]bool)
+	names := make([]string, 0)
+	for _, routes := range users {
+		for _, route := range routes {
+			if route.header != "" && !seen[route.header] {
+				seen[route.header] = true
+				names = append(names, route.header)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// addRoute adds the entry to the routes of a user, keeping
+// the most specific ones first.
+func addRoute(routes []*entry, e *entry, user string, line int) []*entry {
+	for j, route := range routes {
+		if route.isSameRoute(e) {
+			log.Printf("Notice: Domain %s was defined more than once on line %d", user, line)
+			routes[j] = e
+			return routes
+		}
+	}
+
+	routes = append(routes, e)
+	sort.SliceStable(routes, func(a, b int) bool {
+		return routes[a].isMoreSpecific(routes[b])
+	})
+
+	return routes
+}
+
+// GetServerForUser returns the server of the user's requests
+// that match no specific path or header.
 func (f *Filemap) GetServerForUser(user string) (string, uint32, error) {
+	route, err := f.GetRouteForUser(user, "/", nil)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return route.Server, route.Port, nil
+}
+
+// lookup returns the routes of the user. Names are matched exactly
+// first, then by the wildcard with the longest suffix, e.g. either
+// '*.b.example.com' or '*.example.com' for 'a.b.example.com'.
+// Finally, the first regular expression that matches is used.
+// The name of the entry that matched is returned along with them.
+func (f *Filemap) lookup(user string) ([]*entry, string, bool) {
+	user = hostname.Normalize(user)
+
 	f.RLock()
-	entry, exists := f.users[user]
-	f.RUnlock()
+	defer f.RUnlock()
+
+	if routes, ok := f.users[user]; ok {
+		return routes, user, true
+	}
+
+	for _, wildcard := range hostname.Wildcards(user) {
+		if routes, ok := f.users[wildcard]; ok {
+			return routes, wildcard, true
+		}
+	}
+
+	for _, regex := range f.regexes {
+		if regex.regex.MatchString(user) {
+			return f.users[regex.user], regex.user, true
+		}
+	}
+
+	return nil, "", false
+}
 
+func (f *Filemap) GetRouteForUser(user, path string, header map[string]string) (*userbackend.Route, error) {
+	routes, name, exists := f.lookup(user)
 	if !exists {
-		return "", 0, fmt.Errorf("No mapping could be found for user '%s'", user)
+		return nil, fmt.Errorf("No mapping could be found for user '%s'", user)
 	}
 
-	host, portStr, err := net.SplitHostPort(entry)
+	var entry *entry
+	for _, route := range routes {
+		if route.matches(path, header) {
+			entry = route
+			break
+		}
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("No route of user '%s' matches path '%s'", user, path)
+	}
+
+	host, portStr, err := net.SplitHostPort(entry.server)
 	if err != nil {
-		return host, 0, err
+		return nil, err
 	}
 
 	port, err := strconv.Atoi(portStr)
 	if err != nil {
-		return host, 0, fmt.Errorf("Could not parse port from file map: %s", err.Error())
+		return nil, fmt.Errorf("Could not parse port from file map: %s", err.Error())
+	}
+
+	route := &userbackend.Route{User: name, Server: host, Port: uint32(port)}
+	if entry.stripPrefix {
+		route.StripPrefix = entry.pathPrefix
+	}
+
+	return route, nil
+}
+
+func (f *Filemap) HeaderNames() []string {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.headerNames
+}
+
+func (f *Filemap) IsPassthrough(user string) bool {
+	routes, _, exists := f.lookup(user)
+	if !exists {
+		return false
+	}
+
+	// Only the route that applies to the user as a whole counts
+	for _, route := range routes {
+		if route.pathPrefix == "" && route.header == "" {
+			return route.passthrough
+		}
 	}
 
-	return host, uint32(port), nil
+	return false
 }
@@ -0,0 +1,136 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package Filemap
+
+import (
+	"reflect"
+	"testing"
+
+	"diato/userbackend"
+)
+
+const testMap = `
+example.com 10.0.0.1:80
+example.com path=/api 10.0.0.2:80
+example.com path=/api/v2 strip 10.0.0.3:80
+example.com header=x-tenant:beta 10.0.0.4:80
+*.example.com 10.0.1.1:80
+*.b.example.com 10.0.1.2:80
+~tenant-[0-9]+\.example\.org 10.0.2.1:80
+Passthrough.example.net. passthrough 10.0.3.1:443
+`
+
+func newTestFilemap(t *testing.T) *Filemap {
+	f := &Filemap{}
+	if err := f.updateWithContents([]byte(testMap)); err != nil {
+		t.Fatalf("Could not load user map: %s", err.Error())
+	}
+
+	return f
+}
+
+func TestGetRouteForUser(t *testing.T) {
+	f := newTestFilemap(t)
+
+	tests := []struct {
+		user   string
+		path   string
+		header map[string]string
+		want   *userbackend.Route // nil if no route should be found
+	}{
+		{"example.com", "/", nil, &userbackend.Route{User: "example.com", Server: "10.0.0.1", Port: 80}},
+		{"Example.COM.:8080", "/", nil, &userbackend.Route{User: "example.com", Server: "10.0.0.1", Port: 80}},
+		{"example.com", "/api", nil, &userbackend.Route{User: "example.com", Server: "10.0.0.2", Port: 80}},
+		{"example.com", "/api/users", nil, &userbackend.Route{User: "example.com", Server: "10.0.0.2", Port: 80}},
+		{"example.com", "/apis", nil, &userbackend.Route{User: "example.com", Server: "10.0.0.1", Port: 80}},
+		{
+			"example.com", "/api/v2/users", nil,
+			&userbackend.Route{User: "example.com", Server: "10.0.0.3", Port: 80, StripPrefix: "/api/v2"},
+		},
+		{
+			"example.com", "/api", map[string]string{"X-Tenant": "beta"},
+			&userbackend.Route{User: "example.com", Server: "10.0.0.4", Port: 80},
+		},
+		{
+			"example.com", "/", map[string]string{"X-Tenant": "alpha"},
+			&userbackend.Route{User: "example.com", Server: "10.0.0.1", Port: 80},
+		},
+		{"a.example.com", "/", nil, &userbackend.Route{User: "*.example.com", Server: "10.0.1.1", Port: 80}},
+		{"b.example.com", "/", nil, &userbackend.Route{User: "*.example.com", Server: "10.0.1.1", Port: 80}},
+		{"a.b.example.com", "/", nil, &userbackend.Route{User: "*.b.example.com", Server: "10.0.1.2", Port: 80}},
+		{
+			"tenant-12.example.org", "/", nil,
+			&userbackend.Route{User: `~tenant-[0-9]+\.example\.org`, Server: "10.0.2.1", Port: 80},
+		},
+		{"tenant-12.example.org.evil.net", "/", nil, nil},
+		{"tenant-x.example.org", "/", nil, nil},
+		{"example.org", "/", nil, nil},
+		{"passthrough.example.net", "/", nil, &userbackend.Route{User: "passthrough.example.net", Server: "10.0.3.1", Port: 443}},
+	}
+
+	for _, test := range tests {
+		got, err := f.GetRouteForUser(test.user, test.path, test.header)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("GetRouteForUser(%q, %q) = %+v, want an error", test.user, test.path, got)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("GetRouteForUser(%q, %q) returned error: %s", test.user, test.path, err.Error())
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("GetRouteForUser(%q, %q) = %+v, want %+v", test.user, test.path, got, test.want)
+		}
+	}
+}
+
+func TestIsPassthrough(t *testing.T) {
+	f := newTestFilemap(t)
+
+	tests := []struct {
+		user string
+		want bool
+	}{
+		{"passthrough.example.net", true},
+		{"PASSTHROUGH.example.net:443", true},
+		{"example.com", false},
+		{"unknown.example.net", false},
+	}
+
+	for _, test := range tests {
+		if got := f.IsPassthrough(test.user); got != test.want {
+			t.Errorf("IsPassthrough(%q) = %t, want %t", test.user, got, test.want)
+		}
+	}
+}
+
+func TestHeaderNames(t *testing.T) {
+	f := newTestFilemap(t)
+
+	if got, want := f.HeaderNames(), []string{"X-Tenant"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("HeaderNames() = %q, want %q", got, want)
+	}
+}
+
+func TestMinEntries(t *testing.T) {
+	f := &Filemap{minEntries: 10}
+	if err := f.updateWithContents([]byte(testMap)); err == nil {
+		t.Error("Loaded a map with fewer entries than required")
+	}
+}
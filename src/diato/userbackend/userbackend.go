@@ -2,4 +2,34 @@ package userbackend
 
 type Userbackend interface {
 	GetServerForUser(string) (string, uint32, error)
+
+	// The route a request of the user takes, which may depend on
+	// its path and headers. Header names are canonicalized.
+	GetRouteForUser(user, path string, header map[string]string) (*Route, error)
+
+	// The canonical names of the headers that routes depend on, sorted.
+	// Other headers need not be passed to GetRouteForUser().
+	HeaderNames() []string
+
+	// Whether TLS for the user is passed on to its server as is,
+	// rather than being terminated by us
+	IsPassthrough(string) bool
+
+	// The number of users currently known
+	Size() int
+
+	// Reload the backend from its source
+	Reload() error
+}
+
+// Route holds the server a request is sent to
+type Route struct {
+	// The entry of the backend that matched, e.g. '*.example.com'
+	User string
+
+	Server string
+	Port   uint32
+
+	// Removed from the path of the request, if set
+	StripPrefix string
 }
@@ -0,0 +1,59 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hostname normalizes host names so they can be compared
+package hostname
+
+import (
+	"net"
+	"strings"
+)
+
+// Normalize lower cases the name, and strips it of any port,
+// brackets around IPv6 addresses and trailing dots. So that
+// 'Example.COM.:8080' becomes 'example.com'.
+func Normalize(name string) string {
+	if host, _, err := net.SplitHostPort(name); err == nil {
+		name = host
+	} else if strings.HasPrefix(name, "[") && strings.HasSuffix(name, "]") {
+		name = name[1 : len(name)-1]
+	}
+
+	name = strings.ToLower(name)
+	for len(name) > 0 && name[len(name)-1] == '.' {
+		name = name[:len(name)-1]
+	}
+
+	return name
+}
+
+// Wildcards returns the wildcards that match the name, longest
+// suffix first. So for 'a.b.example.com' those are '*.b.example.com',
+// '*.example.com' and '*.com'.
+func Wildcards(name string) []string {
+	res := make([]string, 0, strings.Count(name, "."))
+	for i := strings.Index(name, "."); i != -1; {
+		res = append(res, "*"+name[i:])
+
+		next := strings.Index(name[i+1:], ".")
+		if next == -1 {
+			break
+		}
+		i += next + 1
+	}
+
+	return res
+}
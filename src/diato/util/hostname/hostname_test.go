@@ -0,0 +1,67 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package hostname
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"Example.COM", "example.com"},
+		{"example.com:8080", "example.com"},
+		{"example.com.", "example.com"},
+		{"Example.COM.:8080", "example.com"},
+		{"example.com..", "example.com"},
+		{"127.0.0.1:80", "127.0.0.1"},
+		{"[::1]:443", "::1"},
+		{"[::1]", "::1"},
+		{"::1", "::1"},
+		{"[2001:DB8::1]", "2001:db8::1"},
+		{"*.Example.com", "*.example.com"},
+		{"", ""},
+		{".", ""},
+	}
+
+	for _, test := range tests {
+		if got := Normalize(test.name); got != test.want {
+			t.Errorf("Normalize(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWildcards(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"a.b.example.com", []string{"*.b.example.com", "*.example.com", "*.com"}},
+		{"example.com", []string{"*.com"}},
+		{"localhost", []string{}},
+		{"", []string{}},
+	}
+
+	for _, test := range tests {
+		if got := Wildcards(test.name); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Wildcards(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+// Package rulesync distributes rules from a file to the workers. The
+// server reads the file, and reads it again whenever it changes, each
+// time bumping the version of the rules. Workers poll the server, which
+// only sends the rules along if their version changed.
+package rulesync
+
+import (
+	"log"
+	"sync"
+
+	"diato/util/watch"
+)
+
+// ParseFunc parses the file at the given path. Besides the rules
+// it returns how many there are, to be logged.
+type ParseFunc func(path string) (rules interface{}, count int, err error)
+
+// File holds the rules read from a file, on the server
+type File struct {
+	name  string
+	path  string
+	parse ParseFunc
+
+	sync.RWMutex
+	rules   interface{}
+	version uint64
+}
+
+// NewFile reads the rules from the file at the given path, and reads
+// them again whenever it changes. The name describes the rules in log
+// messages, e.g. 'geoblock rules'.
+func NewFile(name, path string, parse ParseFunc) (*File, error) {
+	f := &File{
+		name:  name,
+		path:  path,
+		parse: parse,
+	}
+
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	err := watch.File(path, func() {
+		if err := f.load(); err != nil {
+			log.Printf("Could not reload %s, keeping the previous ones: %s", name, err.Error())
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+// load (re)loads the rules. Workers pick them up
+// next time they ask, as the version was bumped.
+func (f *File) load() error {
+	rules, count, err := f.parse(f.path)
+	if err != nil {
+		return err
+	}
+
+	f.Lock()
+	f.rules = rules
+	f.version++
+	f.Unlock()
+
+	log.Printf("Loaded %d %s", count, f.name)
+	return nil
+}
+
+// Get returns the rules and their version. The rules are nil if
+// they are of the version the worker asking already knows.
+func (f *File) Get(knownVersion uint64) (interface{}, uint64) {
+	f.RLock()
+	defer f.RUnlock()
+
+	if f.version == knownVersion {
+		return nil, f.version
+	}
+
+	return f.rules, f.version
+}
+
+// Rules returns the current rules
+func (f *File) Rules() interface{} {
+	f.RLock()
+	defer f.RUnlock()
+
+	return f.rules
+}
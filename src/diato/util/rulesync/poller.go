@@ -0,0 +1,122 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package rulesync
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"diato/util/stop"
+)
+
+// How often workers ask the server for new rules
+const pollInterval = 5 * time.Second
+
+// Rules is implemented by the responses of the
+// endpoints modules fetch their rules through.
+type Rules interface {
+	GetVersion() uint64
+	GetUnchanged() bool
+}
+
+// FetchFunc asks the server for the rules, which it only sends
+// along if their version differs from the known one.
+type FetchFunc func(ctx context.Context, knownVersion uint64) (Rules, error)
+
+// BuildFunc turns the rules received into the form requests are
+// matched against. Besides that it returns how many rules there
+// are, to be logged.
+type BuildFunc func(res Rules) (set interface{}, count int, err error)
+
+// Poller keeps the rules of a module up to date, on the worker
+type Poller struct {
+	name  string
+	fetch FetchFunc
+	build BuildFunc
+
+	version uint64 // Only used by update(), which never runs concurrently
+	current atomic.Value
+}
+
+// NewPoller fetches the rules from the server, and keeps asking for
+// updates in the background. The name describes the rules in log
+// messages, e.g. 'geoblock rules'.
+func NewPoller(name string, fetch FetchFunc, build BuildFunc) (*Poller, error) {
+	p := &Poller{
+		name:  name,
+		fetch: fetch,
+		build: build,
+	}
+
+	if err := p.update(); err != nil {
+		return nil, fmt.Errorf("Could not fetch %s: %s", name, err.Error())
+	}
+
+	go p.pollLoop()
+	return p, nil
+}
+
+// Current returns the rules as returned by the BuildFunc
+func (p *Poller) Current() interface{} {
+	return p.current.Load()
+}
+
+func (p *Poller) pollLoop() {
+	ticker := time.NewTicker(pollInterval)
+	stopper := stop.NewStopper(func() {
+		ticker.Stop()
+	})
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := p.update(); err != nil {
+				log.Printf("Could not update %s: %s", p.name, err.Error())
+			}
+		case <-stopper.ShouldStop():
+			return
+		}
+	}
+}
+
+// update fetches the rules from the server, if they changed
+func (p *Poller) update() error {
+	ctx, cancel := context.WithTimeout(context.Background(), pollInterval)
+	defer cancel()
+
+	res, err := p.fetch(ctx, p.version)
+	if err != nil {
+		return err
+	}
+	if res.GetUnchanged() {
+		return nil
+	}
+
+	set, count, err := p.build(res)
+	if err != nil {
+		return err
+	}
+
+	p.current.Store(set)
+	if p.version != 0 {
+		log.Printf("Loaded %d updated %s", count, p.name)
+	}
+	p.version = res.GetVersion()
+	return nil
+}
@@ -39,12 +39,15 @@ type Stopper struct {
 	callback func()
 }
 
+// Stop stops everything, in the reverse order in which it was set up.
+// What depends on something else, like a connection, thus gets to
+// finish up before that is torn down.
 func Stop() {
 	trackedStoppers.Lock()
 	trackedStoppers.stopped = true
 
-	for _, stopper := range trackedStoppers.stoppers {
-		stopper.Stop()
+	for i := len(trackedStoppers.stoppers) - 1; i >= 0; i-- {
+		trackedStoppers.stoppers[i].Stop()
 	}
 	trackedStoppers.Unlock()
 }
@@ -0,0 +1,48 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package urlpath
+
+import (
+	"testing"
+)
+
+func TestHasPrefix(t *testing.T) {
+	tests := []struct {
+		path   string
+		prefix string
+		want   bool
+	}{
+		{"/api", "/api", true},
+		{"/api/", "/api", true},
+		{"/api/users", "/api", true},
+		{"/apis", "/api", false},
+		{"/api-v2", "/api", false},
+		{"/ap", "/api", false},
+		{"/api/users", "/api/", true},
+		{"/api", "/api/", false},
+		{"/", "/", true},
+		{"/anything", "/", true},
+		{"/anything", "", true},
+		{"", "", true},
+		{"/API", "/api", false},
+	}
+
+	for _, test := range tests {
+		if got := HasPrefix(test.path, test.prefix); got != test.want {
+			t.Errorf("HasPrefix(%q, %q) = %t, want %t", test.path, test.prefix, got, test.want)
+		}
+	}
+}
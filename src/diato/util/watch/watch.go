@@ -0,0 +1,62 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watch notifies about changes to files on disk
+package watch
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"diato/util/stop"
+
+	"github.com/rjeczalik/notify"
+)
+
+// File calls onChange whenever the file at the given path is written
+// to or replaced. Its directory is watched rather than the file itself,
+// as most editors and tools like geoipupdate replace a file by renaming
+// a new one over it, which would end a watch on the file itself.
+func File(path string, onChange func()) error {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("Could not determine absolute path to '%s': %s", path, err.Error())
+	}
+
+	c := make(chan notify.EventInfo, 16)
+	if err := notify.Watch(filepath.Dir(path), c, notify.Create, notify.Write, notify.Rename); err != nil {
+		return fmt.Errorf("Could not watch '%s': %s", path, err.Error())
+	}
+
+	stopper := stop.NewStopper(func() {
+		notify.Stop(c)
+	})
+
+	go func() {
+		for {
+			select {
+			case event := <-c:
+				if event.Path() == path {
+					onChange()
+				}
+			case <-stopper.ShouldStop():
+				return
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,111 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// BodyFilterModule is implemented by modules that rewrite or inspect
+// response bodies, like HTML rewriting or content injection. Bodies are
+// streamed through the filters as they're sent to the client, so a filter
+// should not hold on to more of the body than it needs to.
+type BodyFilterModule interface {
+	Module
+
+	// The media types the filter applies to, e.g. 'text/html'. A
+	// trailing wildcard ('text/*') matches any subtype. Nil applies
+	// the filter to all responses.
+	FilterContentTypes() []string
+
+	// FilterBody returns the body to send to the client in place of the
+	// given one, which it's expected to read from. Closing the returned
+	// body must close the given one. The ContextInfo of the request is
+	// in its context. Returning body as is leaves the response alone.
+	FilterBody(req *http.Request, res *http.Response, body io.ReadCloser) io.ReadCloser
+}
+
+// FilterBody runs the response body through the filters that apply to
+// it. If any filter replaced the body, its length is no longer known
+// and the response is sent chunked.
+func (r *moduleRegistry) FilterBody(res *http.Response) {
+	if len(r.bodyFilters) == 0 || !isFilterable(res) {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		mediaType = ""
+	}
+
+	body := res.Body
+	for _, filter := range r.bodyFilters {
+		if matchesContentType(mediaType, filter.FilterContentTypes()) {
+			body = filter.FilterBody(res.Request, res, body)
+		}
+	}
+	if body == res.Body {
+		return
+	}
+
+	res.Body = body
+	res.ContentLength = -1
+	res.Header.Del("Content-Length")
+	res.Header.Del("Content-Md5")
+	res.Header.Del("Accept-Ranges") // Offsets no longer match up
+}
+
+// isFilterable tells if the response has a body that filters
+// can make sense of. Compressed bodies are left alone.
+func isFilterable(res *http.Response) bool {
+	switch {
+	case res.StatusCode < 200,
+		res.StatusCode == http.StatusNoContent,
+		res.StatusCode == http.StatusPartialContent,
+		res.StatusCode == http.StatusNotModified:
+		return false
+	case res.Request != nil && res.Request.Method == "HEAD":
+		return false
+	}
+
+	encoding := res.Header.Get("Content-Encoding")
+	return encoding == "" || strings.EqualFold(encoding, "identity")
+}
+
+func matchesContentType(mediaType string, contentTypes []string) bool {
+	if contentTypes == nil {
+		return true
+	}
+
+	for _, contentType := range contentTypes {
+		contentType = strings.ToLower(contentType)
+		if strings.HasSuffix(contentType, "/*") {
+			if strings.HasPrefix(mediaType, contentType[:len(contentType)-1]) {
+				return true
+			}
+			continue
+		}
+
+		if mediaType == contentType {
+			return true
+		}
+	}
+
+	return false
+}
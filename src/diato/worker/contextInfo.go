@@ -21,8 +21,13 @@ import (
 	"math/rand"
 	"net/http"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
+	pb "diato/pb"
+	"diato/util/hostname"
+
 	"github.com/Freeaqingme/publicsuffix-go/publicsuffix"
 	"github.com/bwmarrin/snowflake"
 	ua "github.com/mssola/user_agent"
@@ -45,16 +50,42 @@ type ContextInfo struct {
 	timeStart time.Time
 
 	// Keep it at 64 bits because modsecurity uses 64 bit id's for logging
-	requestId int64
-	sld       string
-	userAgent *ua.UserAgent
+	requestId  int64
+	host       string
+	requestUri string // Before modules rewrite it
+	sld        string
+	userAgent  *ua.UserAgent
+	geoIp      *pb.GeoIpResponse
+
+	// How the client connected to us
+	tls    bool
+	listen string
+
+	// Set by modules answering the request themselves
+	interventionLock sync.Mutex
+	intervention     *Intervention
+
+	// The entry of the user backend the request was routed by
+	user string
+
+	// Populated as the request is proxied to the upstream
+	bytesReceived       int64
+	upstreamConnectTime time.Duration
+	upstreamTtfb        time.Duration
+
+	// Populated as the response is sent to the client
+	responseStatus int
+	bytesSent      int64
+	timeEnd        time.Time
 }
 
 func getRequestWithContextInfo(r *http.Request) *http.Request {
 	contextInfo := &ContextInfo{
-		timeStart: time.Now(),
-		requestId: snowflakeGenerator.Generate().Int64(),
-		userAgent: ua.New(r.UserAgent()),
+		timeStart:  time.Now(),
+		requestId:  snowflakeGenerator.Generate().Int64(),
+		host:       hostname.Normalize(r.Host),
+		requestUri: r.URL.RequestURI(),
+		userAgent:  ua.New(r.UserAgent()),
 	}
 	contextInfo.setSld(r)
 
@@ -74,6 +105,19 @@ func (i *ContextInfo) RequestIdString() string {
 	return strconv.FormatInt(i.RequestId(), 36)
 }
 
+// Host returns the host the request was sent to, normalized so that
+// modules keying rules on it can't be bypassed with something like
+// 'EXAMPLE.com.:443'.
+func (i *ContextInfo) Host() string {
+	return i.host
+}
+
+// RequestUri returns the path and query the client asked for,
+// before modules rewrote them or a route stripped their prefix.
+func (i *ContextInfo) RequestUri() string {
+	return i.requestUri
+}
+
 func (i *ContextInfo) Sld() string {
 	return i.sld
 }
@@ -82,6 +126,64 @@ func (i *ContextInfo) UserAgent() *ua.UserAgent {
 	return i.userAgent
 }
 
+// Tls tells if the client connected over TLS
+func (i *ContextInfo) Tls() bool {
+	return i.tls
+}
+
+// Listen returns the name of the listen section the request came in
+// through, or an empty string if that could not be determined.
+func (i *ContextInfo) Listen() string {
+	return i.listen
+}
+
+// GeoIp returns the location and network of the client. It's nil if
+// GeoIP is not enabled or the lookup failed. Fields are empty if the
+// databases don't know of the client, e.g. CountryIsoCode is "" and
+// Asn is 0 for private addresses.
+func (i *ContextInfo) GeoIp() *pb.GeoIpResponse {
+	return i.geoIp
+}
+
+// BytesReceived returns the size of the request body received from the client
+func (i *ContextInfo) BytesReceived() int64 {
+	return atomic.LoadInt64(&i.bytesReceived)
+}
+
+// UpstreamConnectTime returns how long it took to connect to the
+// upstream. It's zero if an idle connection was reused.
+func (i *ContextInfo) UpstreamConnectTime() time.Duration {
+	return i.upstreamConnectTime
+}
+
+// UpstreamTtfb returns the time from starting the request to the
+// upstream, including connecting, until the first byte of its
+// response came in.
+func (i *ContextInfo) UpstreamTtfb() time.Duration {
+	return i.upstreamTtfb
+}
+
+// ResponseStatus returns the status code that was sent to the client
+func (i *ContextInfo) ResponseStatus() int {
+	return i.responseStatus
+}
+
+// BytesSent returns the size of the response body sent to the client
+func (i *ContextInfo) BytesSent() int64 {
+	return i.bytesSent
+}
+
+// TimeEnd returns when the response was completely sent. It's
+// the zero time for as long as the response is in progress.
+func (i *ContextInfo) TimeEnd() time.Time {
+	return i.timeEnd
+}
+
+// Duration returns the total time it took to handle the request
+func (i *ContextInfo) Duration() time.Duration {
+	return i.timeEnd.Sub(i.timeStart)
+}
+
 func (i *ContextInfo) setSld(r *http.Request) {
 	i.sld, _ = publicsuffix.DomainFromListWithOptions(
 		publicsuffix.DefaultList,
@@ -0,0 +1,102 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	pb "diato/pb"
+)
+
+// Lookups are cached, as most clients make more than one request
+const (
+	geoIpCacheTtl  = 5 * time.Minute
+	geoIpCacheSize = 65536
+)
+
+type geoIpCacheEntry struct {
+	response *pb.GeoIpResponse
+	expires  time.Time
+}
+
+type geoIpCache struct {
+	sync.Mutex
+	entries map[string]geoIpCacheEntry
+}
+
+func newGeoIpCache() *geoIpCache {
+	return &geoIpCache{
+		entries: make(map[string]geoIpCacheEntry),
+	}
+}
+
+func (c *geoIpCache) get(ip string) (*pb.GeoIpResponse, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	entry, ok := c.entries[ip]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+
+	return entry.response, true
+}
+
+func (c *geoIpCache) set(ip string, response *pb.GeoIpResponse) {
+	c.Lock()
+	defer c.Unlock()
+
+	// Simply starting over is cheaper than keeping track of
+	// which entry was used least recently.
+	if len(c.entries) >= geoIpCacheSize {
+		c.entries = make(map[string]geoIpCacheEntry)
+	}
+
+	c.entries[ip] = geoIpCacheEntry{response, time.Now().Add(geoIpCacheTtl)}
+}
+
+// geoIpLookup populates the GeoIP information of the request's
+// ContextInfo. It's left empty if GeoIP is not enabled, or if
+// the client could not be looked up.
+func (w *Worker) geoIpLookup(req *http.Request) {
+	if w.geoIp == nil {
+		return
+	}
+
+	ip, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return
+	}
+
+	ctxInfo := req.Context().Value("diato").(*ContextInfo)
+	if response, ok := w.geoIpCache.get(ip); ok {
+		ctxInfo.geoIp = response
+		return
+	}
+
+	response, err := w.geoIp.Lookup(req.Context(), &pb.GeoIpRequest{Ip: ip})
+	if err != nil {
+		log.Printf("Could not look up GeoIP information of %s: %s", ip, err.Error())
+		return
+	}
+
+	w.geoIpCache.set(ip, response)
+	ctxInfo.geoIp = response
+}
@@ -0,0 +1,42 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"io"
+	"net/http"
+)
+
+// The Host of the requests the server makes to check if we're still
+// serving requests. Keep in sync with healthCheckHost in server/health.go.
+const healthCheckHost = "_diato-health"
+
+// healthHandler answers the health checks of the server itself,
+// so they don't end up with the modules or any backend.
+type healthHandler struct {
+	http.Handler
+}
+
+func (h *healthHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Host != healthCheckHost {
+		h.Handler.ServeHTTP(rw, req)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("Content-Length", "3")
+	io.WriteString(rw, "OK\n")
+}
@@ -18,14 +18,18 @@ package worker
 import (
 	"context"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"os"
+	"strings"
 	"time"
 
+	"diato/config"
+	"diato/metrics"
 	pb "diato/pb"
+	"diato/util/rulesync"
 	"diato/util/stop"
 
 	"github.com/Freeaqingme/go-proxyproto"
@@ -47,11 +51,14 @@ func (w *Worker) httpGetListener(tls bool) (net.Listener, error) {
 	return &proxyproto.Listener{Listener: httpLn}, nil
 }
 
-func (w *Worker) httpListen(httpSocket net.Listener, tls bool) {
+func (w *Worker) httpListen(httpSocket net.Listener, tls bool, config *config.Config) {
+	limits := httpLimits(config, tls)
 	srv := &http.Server{
-		ReadTimeout: 5 * time.Second,
-		IdleTimeout: 120 * time.Second,
-		Handler:     w.newHttpHandler(tls),
+		ReadTimeout:       5 * time.Second,
+		ReadHeaderTimeout: limits.HeaderReadTimeout,
+		IdleTimeout:       limits.IdleTimeout,
+		MaxHeaderBytes:    limits.MaxHeaderSize,
+		Handler:           &healthHandler{w.newHttpHandler(tls, config)},
 	}
 
 	stop.NewStopper(func() {
@@ -65,13 +72,71 @@ func (w *Worker) httpListen(httpSocket net.Listener, tls bool) {
 	srv.Serve(httpSocket)
 }
 
-func (w *Worker) newHttpHandler(tls bool) *ReverseProxy {
+// httpLimits returns the most permissive limits of the listen sections
+// that share the socket, as we can't tell which one a request came
+// in through. The server enforces the limits on the first request of
+// each connection, this covers the ones that follow.
+func httpLimits(conf *config.Config, tls bool) *config.ListenLimits {
+	res := &config.ListenLimits{}
+	first := true
+	for _, l := range conf.Listen {
+		if l.TlsEnable != tls {
+			continue
+		}
+
+		limits, _ := l.Limits() // Validated when the config was read
+		if first {
+			res, first = limits, false
+			continue
+		}
+
+		res.MaxHeaderSize = maxInt(res.MaxHeaderSize, limits.MaxHeaderSize)
+		res.HeaderReadTimeout = maxTimeout(res.HeaderReadTimeout, limits.HeaderReadTimeout)
+		res.IdleTimeout = maxTimeout(res.IdleTimeout, limits.IdleTimeout)
+	}
+
+	return res
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// maxTimeout returns the longest timeout, zero meaning there is none
+func maxTimeout(a, b time.Duration) time.Duration {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func (w *Worker) newHttpHandler(tls bool, conf *config.Config) *ReverseProxy {
+	listens := newListenMatcher(conf, tls)
 	director := func(req *http.Request) {
+		ctxInfo := req.Context().Value("diato").(*ContextInfo)
+		ctxInfo.tls = tls
+		if addr, ok := req.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+			ctxInfo.listen = listens.match(addr)
+		}
+
+		w.geoIpLookup(req)
 		w.modules.ProcessRequest(req)
+		if ctxInfo.Intervention() == nil {
+			w.modules.RewriteRequest(req)
+		}
+		if ctxInfo.Intervention() != nil {
+			return // No need to look up a backend
+		}
+		w.modules.ModifyRequestHeader(req)
 		// local addr: fmt.Println(req.Context().Value(http.LocalAddrContextKey).(net.Addr))
 
 		var err error
-		ctxInfo := req.Context().Value("diato").(*ContextInfo)
 		req.URL.Scheme = "http"
 		req.URL.Host, err = w.getHttpBackend(req)
 		if err != nil {
@@ -82,22 +147,14 @@ func (w *Worker) newHttpHandler(tls bool) *ReverseProxy {
 		if tls {
 			req.Header.Add("X-Forwarded-Proto", "https")
 		}
-
-		log.Printf("%s %s '%s %s %s' -> %s '%s' (SSL: %t)\n",
-			req.RemoteAddr,
-			ctxInfo.RequestIdString(),
-			req.Method, req.RequestURI,
-			req.Host,
-			req.URL.Host,
-			req.UserAgent(),
-			tls,
-		)
 	}
 
 	return &ReverseProxy{
-		Director:      director,
-		FlushInterval: 10 * time.Millisecond,
-		Transport: &httpTransport{
+		Director:           director,
+		FlushInterval:      10 * time.Millisecond,
+		UpgradeIdleTimeout: httpLimits(conf, tls).IdleTimeout,
+		BufferPool:         newBufferPool(),
+		Transport: w.modules.WrapTransport(&httpTransport{
 			&http.Transport{
 				Proxy: http.ProxyFromEnvironment,
 				Dial: (&net.Dialer{
@@ -106,72 +163,110 @@ func (w *Worker) newHttpHandler(tls bool) *ReverseProxy {
 				}).Dial,
 				MaxIdleConnsPerHost: 64,
 			},
-		},
+		}),
 		ModifyResponse: func(r *http.Response) error {
 			// TODO: If backend is unavailable this header is never added
 			r.Header.Add("X-Powered-By", "Diato")
+			w.modules.ModifyResponseHeader(r)
 
 			go w.modules.PostModifyResponse(r)
 			return nil
 		},
+		FilterBody: func(r *http.Response) {
+			w.modules.FilterBody(r)
+		},
+		PostResponse: func(r *http.Request) {
+			metricsRecordRequest(r)
+			go w.modules.PostResponse(r)
+		},
 	}
 }
 
+// getHttpBackend returns the backend of the request, which depends on
+// its host and possibly its path and headers. The path is stripped of
+// its prefix if the route says so.
 func (w *Worker) getHttpBackend(req *http.Request) (string, error) {
+	// Only the headers requests are routed by are sent along, so
+	// cookies and credentials don't needlessly end up with the server.
+	var headers map[string]string
+	if names := w.routingHeaders.Current().([]string); len(names) > 0 {
+		headers = make(map[string]string, len(names))
+		for _, name := range names {
+			if value := req.Header.Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+	}
+
 	r, err := w.userBackend.GetServerForUser(
 		req.Context(),
-		&pb.UserBackendRequest{Name: req.Host},
+		&pb.UserBackendRequest{Name: req.Host, Path: req.URL.Path, Headers: headers},
 	)
 	if err != nil {
 		return "", err
 	}
+	if ctxInfo, ok := req.Context().Value("diato").(*ContextInfo); ok {
+		ctxInfo.user = r.User
+	}
+
+	if r.StripPrefix != "" {
+		req.URL.Path = strings.TrimPrefix(req.URL.Path, r.StripPrefix)
+		if !strings.HasPrefix(req.URL.Path, "/") {
+			req.URL.Path = "/" + req.URL.Path
+		}
+		req.URL.RawPath = ""
+	}
 
 	return fmt.Sprintf("%s:%d", r.Server, r.Port), nil
 }
 
+// routingHeadersInit keeps track of the headers requests are routed by
+func (w *Worker) routingHeadersInit() (*rulesync.Poller, error) {
+	return rulesync.NewPoller("routing headers", func(ctx context.Context, version uint64) (rulesync.Rules, error) {
+		return w.userBackend.GetRoutingHeaders(ctx, &pb.RoutingHeadersRequest{Version: version})
+	}, func(res rulesync.Rules) (interface{}, int, error) {
+		names := res.(*pb.RoutingHeaders).Names
+		return names, len(names), nil
+	})
+}
+
+// newUpstreamTrace records the timing of the request to the upstream
+func newUpstreamTrace(ctxInfo *ContextInfo, start time.Time) *httptrace.ClientTrace {
+	var connectStart time.Time
+
+	return &httptrace.ClientTrace{
+		ConnectStart: func(network, addr string) {
+			connectStart = time.Now()
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err == nil {
+				ctxInfo.upstreamConnectTime = time.Since(connectStart)
+			}
+		},
+		GotFirstResponseByte: func() {
+			ctxInfo.upstreamTtfb = time.Since(start)
+		},
+	}
+}
+
 type httpTransport struct {
 	http.RoundTripper
 }
 
 func (t *httpTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	start := time.Now()
+	host := metricsHostOther
+	if ctxInfo, ok := req.Context().Value("diato").(*ContextInfo); ok {
+		host = metricsHost(ctxInfo)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), newUpstreamTrace(ctxInfo, start)))
+	}
+
 	resp, err = t.RoundTripper.RoundTrip(req)
 	if err != nil {
+		metrics.UpstreamErrors.Inc(host)
 		return resp, err
 	}
+	metrics.UpstreamDuration.Observe(time.Since(start).Seconds(), host)
 
-	//fmt.Println(resp.Status)
-	//for k, v := range resp.Header {
-	//fmt.Println(k, v)
-	//}
-
-	r := resp.Body
-
-	var writer *io.PipeWriter
-	resp.Body, writer = io.Pipe()
-
-	go func() {
-		defer writer.Close()
-		buf := make([]byte, 0, 4096)
-		for {
-			n, err := r.Read(buf[:cap(buf)])
-			buf = buf[:n]
-			if n == 0 {
-				if err == nil {
-					continue
-				}
-				if err == io.EOF {
-					break
-				}
-				return
-			}
-
-			//fmt.Print(string(buf)) // processing here?
-
-			writer.Write(buf)
-			if err != nil && err != io.EOF {
-				return
-			}
-		}
-	}()
 	return resp, nil
 }
@@ -0,0 +1,70 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// Intervention is a response sent by Diato itself rather than by the
+// upstream. Modules can intervene in a request from ProcessRequest(),
+// e.g. to deny access, in which case it's not proxied at all.
+type Intervention struct {
+	Status int
+	Header http.Header
+
+	// Defaults to the status text if empty
+	Body string
+}
+
+// Intervene makes Diato respond to the request itself. If multiple
+// modules intervene in the same request, the first one wins.
+func (i *ContextInfo) Intervene(intervention *Intervention) {
+	i.interventionLock.Lock()
+	defer i.interventionLock.Unlock()
+
+	if i.intervention == nil {
+		i.intervention = intervention
+	}
+}
+
+// Intervention returns how a module intervened in the request,
+// or nil if it's to be proxied to the upstream.
+func (i *ContextInfo) Intervention() *Intervention {
+	i.interventionLock.Lock()
+	defer i.interventionLock.Unlock()
+
+	return i.intervention
+}
+
+func (p *ReverseProxy) intervene(rw http.ResponseWriter, ctxInfo *ContextInfo, intervention *Intervention) {
+	copyHeader(rw.Header(), intervention.Header)
+
+	body := intervention.Body
+	if body == "" {
+		body = http.StatusText(intervention.Status) + "\n"
+	}
+	if rw.Header().Get("Content-Type") == "" {
+		rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	rw.Header().Set("Content-Length", strconv.Itoa(len(body)))
+
+	ctxInfo.responseStatus = intervention.Status
+	rw.WriteHeader(intervention.Status)
+	n, _ := rw.Write([]byte(body))
+	ctxInfo.bytesSent = int64(n)
+}
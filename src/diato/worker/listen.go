@@ -0,0 +1,94 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"net"
+	"strconv"
+
+	"diato/config"
+)
+
+// listenMatcher tells which listen section a request came in through.
+// Workers share a socket for all listen sections (with or without TLS),
+// but the server passes on the address the client connected to in the
+// PROXY header. That is matched against the binds of the sections.
+type listenMatcher struct {
+	binds []*listenBind
+}
+
+type listenBind struct {
+	name string
+	ip   net.IP // Nil if bound to all addresses
+	port int
+}
+
+func newListenMatcher(conf *config.Config, tls bool) *listenMatcher {
+	m := &listenMatcher{binds: make([]*listenBind, 0)}
+	for name, l := range conf.Listen {
+		if l.TlsEnable != tls {
+			continue
+		}
+
+		host, portStr, err := net.SplitHostPort(l.Bind)
+		if err != nil {
+			continue
+		}
+		port, _ := strconv.Atoi(portStr)
+
+		bind := &listenBind{name: name, port: port}
+		if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+			bind.ip = ip
+		}
+		m.binds = append(m.binds, bind)
+	}
+
+	return m
+}
+
+// match returns the name of the listen section for the address the client
+// connected to. If that's ambiguous, e.g. because the section accepts the
+// PROXY protocol itself, an empty string is returned.
+func (m *listenMatcher) match(addr net.Addr) string {
+	if len(m.binds) == 1 {
+		return m.binds[0].name
+	}
+
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return ""
+	}
+
+	var res string
+	matches := 0
+	for _, bind := range m.binds {
+		if bind.port != tcpAddr.Port {
+			continue
+		}
+		if bind.ip != nil && bind.ip.Equal(tcpAddr.IP) {
+			return bind.name // An exact match beats binding to all addresses
+		}
+		if bind.ip == nil {
+			res = bind.name
+			matches++
+		}
+	}
+
+	if matches != 1 {
+		return ""
+	}
+	return res
+}
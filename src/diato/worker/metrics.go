@@ -0,0 +1,101 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"diato/metrics"
+	"diato/util/stop"
+)
+
+// How often the metrics are reported to the server
+const metricsReportInterval = 10 * time.Second
+
+// The host label of requests the user backend knows nothing of
+const metricsHostOther = "other"
+
+func (w *Worker) metricsReportLoop() {
+	ticker := time.NewTicker(metricsReportInterval)
+	stopper := stop.NewStopper(func() {
+		ticker.Stop()
+	})
+
+	for {
+		select {
+		case <-ticker.C:
+			w.metricsReport()
+		case <-stopper.ShouldStop():
+			return
+		}
+	}
+}
+
+func (w *Worker) metricsReport() {
+	report := metrics.Drain()
+	if len(report.Metrics) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), metricsReportInterval)
+	defer cancel()
+
+	if _, err := w.serverClient.ReportMetrics(ctx, report); err != nil {
+		log.Printf("Could not report metrics: %s", err.Error())
+
+		// Try again next time
+		metrics.Merge(report)
+	}
+}
+
+// metricsRecordRequest records the metrics of a request
+// once its response was sent to the client.
+func metricsRecordRequest(req *http.Request) {
+	ctxInfo := req.Context().Value("diato").(*ContextInfo)
+
+	host := metricsHost(ctxInfo)
+	metrics.HttpRequests.Inc(host, metricsMethod(req.Method), strconv.Itoa(ctxInfo.ResponseStatus()))
+	metrics.HttpRequestBytes.Add(float64(ctxInfo.BytesReceived()), host)
+	metrics.HttpResponseBytes.Add(float64(ctxInfo.BytesSent()), host)
+}
+
+// metricsHost returns the host label of the request, which is the entry
+// of the user backend it was routed by, e.g. '*.example.com'. Clients
+// thus cannot come up with an endless number of series by making up
+// hosts, nor by varying the subdomains a wildcard matches.
+func metricsHost(ctxInfo *ContextInfo) string {
+	if ctxInfo.user == "" {
+		return metricsHostOther
+	}
+
+	return ctxInfo.user
+}
+
+// metricsMethod makes sure clients cannot come up with
+// an endless number of series by using bogus methods.
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+
+	return "OTHER"
+}
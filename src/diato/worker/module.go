@@ -30,12 +30,63 @@ type moduleInitializer func(*Worker) Module
 type Module interface {
 	Enabled() bool
 	Name() string
+
+	// Called before the request is proxied. Modules can answer the
+	// request themselves through ContextInfo.Intervene().
 	ProcessRequest(*http.Request)
 	PostModifyResponse(r *http.Request, w *http.Response)
+
+	// Called once the response was completely sent to the client
+	PostResponse(r *http.Request)
+}
+
+// HeaderModule is implemented by modules that modify the headers of
+// requests and responses. Contrary to ProcessRequest(), which runs all
+// modules in parallel, the modules are called one at a time so they
+// can safely modify the headers.
+type HeaderModule interface {
+	Module
+
+	// Called after ProcessRequest(), unless a module intervened
+	ModifyRequestHeader(req *http.Request)
+
+	// Called before the response header is sent to the client
+	ModifyResponseHeader(res *http.Response)
+}
+
+// RewriteModule is implemented by modules that rewrite requests, or
+// redirect them elsewhere through ContextInfo.Intervene(). Like
+// HeaderModule, the modules are called one at a time.
+type RewriteModule interface {
+	Module
+
+	// Called after ProcessRequest(), unless a module intervened
+	RewriteRequest(req *http.Request)
+}
+
+// TransportModule is implemented by modules that sit between the proxy
+// and the backend, e.g. to answer requests from a cache. The responses
+// they return are treated like any other, so all other modules still
+// see them.
+type TransportModule interface {
+	Module
+
+	// Returns a RoundTripper that's used in place of the one given,
+	// which it passes the requests on to that it doesn't answer itself.
+	WrapTransport(next http.RoundTripper) http.RoundTripper
 }
 
 type moduleRegistry struct {
 	modules []Module
+
+	// The modules that implement BodyFilterModule, in the order they
+	// were registered. Each wraps the body returned by the one before.
+	bodyFilters []BodyFilterModule
+
+	// Likewise, the modules that implement HeaderModule and RewriteModule
+	headerModules    []HeaderModule
+	rewriteModules   []RewriteModule
+	transportModules []TransportModule
 }
 
 var moduleInitializers []func(*Worker, *config.Config) ([]Module, error)
@@ -49,9 +100,15 @@ func RegisterModule(initializer func(*Worker, *config.Config) ([]Module, error))
 }
 
 func (w *Worker) initModules(modules []func(*Worker, *config.Config) ([]Module, error), config *config.Config) error {
+	disabled, err := w.getDisabledModules()
+	if err != nil {
+		return err
+	}
+
 	registry := &moduleRegistry{
 		modules: make([]Module, 0),
 	}
+	names := make([]string, 0)
 	for _, m := range modules {
 		initializedModules, err := m(w, config)
 		if err != nil {
@@ -62,13 +119,30 @@ func (w *Worker) initModules(modules []func(*Worker, *config.Config) ([]Module,
 				log.Printf("Skipping module '%s' because it was not enabled", initializedModule.Name())
 				continue
 			}
+			if disabled[initializedModule.Name()] {
+				log.Printf("Skipping module '%s' because it was disabled through the admin API", initializedModule.Name())
+				continue
+			}
 			log.Printf("Loaded module '%s'", initializedModule.Name())
 			registry.modules = append(registry.modules, initializedModule)
+			if filter, ok := initializedModule.(BodyFilterModule); ok {
+				registry.bodyFilters = append(registry.bodyFilters, filter)
+			}
+			if headerModule, ok := initializedModule.(HeaderModule); ok {
+				registry.headerModules = append(registry.headerModules, headerModule)
+			}
+			if rewriteModule, ok := initializedModule.(RewriteModule); ok {
+				registry.rewriteModules = append(registry.rewriteModules, rewriteModule)
+			}
+			if transportModule, ok := initializedModule.(TransportModule); ok {
+				registry.transportModules = append(registry.transportModules, transportModule)
+			}
+			names = append(names, initializedModule.Name())
 		}
 	}
 
 	w.modules = registry
-	return nil
+	return w.reportModules(names)
 }
 
 func (r *moduleRegistry) ProcessRequest(req *http.Request) {
@@ -77,20 +151,52 @@ func (r *moduleRegistry) ProcessRequest(req *http.Request) {
 		callback := m.ProcessRequest
 		callbacks = append(callbacks, func() { (callback)(req) })
 	}
-	r.parallelCallback(callbacks)
+
+	// Wait for all modules, any of them may intervene in the request
+	for range r.parallelCallback(callbacks) {
+	}
 }
 
-func (r *moduleRegistry) PostModifyResponse(resp *http.Response) {
-	contextInfo := resp.Request.Context().Value("diato")
-	localAddr := resp.Request.Context().Value(http.LocalAddrContextKey)
+// RewriteRequest lets the modules rewrite the request, one after the
+// other. Once a module intervened, the others are skipped.
+func (r *moduleRegistry) RewriteRequest(req *http.Request) {
+	ctxInfo := req.Context().Value("diato").(*ContextInfo)
+	for _, m := range r.rewriteModules {
+		if ctxInfo.Intervention() != nil {
+			return
+		}
+		m.RewriteRequest(req)
+	}
+}
 
-	// Initialize a new context that won't be canceled after the
-	// request has finished
-	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
+// ModifyRequestHeader lets the modules modify the request headers,
+// one after the other, before the request is sent to the backend.
+func (r *moduleRegistry) ModifyRequestHeader(req *http.Request) {
+	for _, m := range r.headerModules {
+		m.ModifyRequestHeader(req)
+	}
+}
 
-	ctx = context.WithValue(ctx, "diato", contextInfo)
-	ctx = context.WithValue(ctx, http.LocalAddrContextKey, localAddr)
-	request := resp.Request.WithContext(ctx)
+// ModifyResponseHeader lets the modules modify the response headers,
+// one after the other, before the response is sent to the client.
+func (r *moduleRegistry) ModifyResponseHeader(res *http.Response) {
+	for _, m := range r.headerModules {
+		m.ModifyResponseHeader(res)
+	}
+}
+
+// WrapTransport wraps the transport with those of the modules. The
+// module registered first gets to see the request first.
+func (r *moduleRegistry) WrapTransport(transport http.RoundTripper) http.RoundTripper {
+	for i := len(r.transportModules) - 1; i >= 0; i-- {
+		transport = r.transportModules[i].WrapTransport(transport)
+	}
+
+	return transport
+}
+
+func (r *moduleRegistry) PostModifyResponse(resp *http.Response) {
+	request := detachRequest(resp.Request)
 
 	callbacks := make([]func(), 0)
 	for _, m := range r.modules {
@@ -100,8 +206,36 @@ func (r *moduleRegistry) PostModifyResponse(resp *http.Response) {
 	r.parallelCallback(callbacks)
 }
 
+func (r *moduleRegistry) PostResponse(req *http.Request) {
+	request := detachRequest(req)
+
+	callbacks := make([]func(), 0)
+	for _, m := range r.modules {
+		callback := m.PostResponse
+		callbacks = append(callbacks, func() { (callback)(request) })
+	}
+	r.parallelCallback(callbacks)
+}
+
+// detachRequest returns a copy of the request with a context that
+// won't be canceled after the request has finished, for modules
+// that do their thing in the background.
+func detachRequest(req *http.Request) *http.Request {
+	contextInfo := req.Context().Value("diato")
+	localAddr := req.Context().Value(http.LocalAddrContextKey)
+
+	ctx, _ := context.WithTimeout(context.Background(), 30*time.Second)
+
+	ctx = context.WithValue(ctx, "diato", contextInfo)
+	ctx = context.WithValue(ctx, http.LocalAddrContextKey, localAddr)
+	return req.WithContext(ctx)
+}
+
+// parallelCallback runs the callbacks concurrently. The channel returned
+// is closed once all of them are done. It has room for all of them, so
+// callers that don't wait for the callbacks need not drain it.
 func (r *moduleRegistry) parallelCallback(callbacks []func()) chan interface{} {
-	out := make(chan interface{}, 8)
+	out := make(chan interface{}, len(callbacks))
 
 	wg := &sync.WaitGroup{}
 	for _, callback := range callbacks {
@@ -126,3 +260,4 @@ type ModuleBase struct {
 
 func (*ModuleBase) ProcessRequest(*http.Request)                     {}
 func (*ModuleBase) PostModifyResponse(*http.Request, *http.Response) {}
+func (*ModuleBase) PostResponse(*http.Request)                       {}
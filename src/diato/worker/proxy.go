@@ -25,6 +25,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -52,6 +53,8 @@ type ReverseProxy struct {
 	// to flush to the client while copying the
 	// response body.
 	// If zero, no periodic flushing is done.
+	// Streaming responses are always flushed
+	// immediately, see isStreamingResponse().
 	FlushInterval time.Duration
 
 	// ErrorLog specifies an optional logger for errors
@@ -69,6 +72,20 @@ type ReverseProxy struct {
 	// modifies the Response from the backend.
 	// If it returns an error, the proxy returns a StatusBadGateway error.
 	ModifyResponse func(*http.Response) error
+
+	// FilterBody is an optional function that may replace the
+	// body of the response, after ModifyResponse was called.
+	FilterBody func(*http.Response)
+
+	// UpgradeIdleTimeout closes connections that switched protocols,
+	// like WebSockets, once no data went either way for this long.
+	// If zero, there's no timeout.
+	UpgradeIdleTimeout time.Duration
+
+	// PostResponse is an optional function that is called once
+	// the response was sent to the client, or failed to be. The
+	// request's ContextInfo then describes the response.
+	PostResponse func(*http.Request)
 }
 
 // A BufferPool is an interface for getting and returning temporary
@@ -117,6 +134,9 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 
 	req = getRequestWithContextInfo(req)
+	ctxInfo := req.Context().Value("diato").(*ContextInfo)
+	defer p.postResponse(req, ctxInfo)
+
 	ctx := req.Context()
 	if cn, ok := rw.(http.CloseNotifier); ok {
 		var cancel context.CancelFunc
@@ -140,10 +160,19 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	outreq.Header = cloneHeader(req.Header)
 
 	p.Director(outreq)
+	if intervention := ctxInfo.Intervention(); intervention != nil {
+		p.intervene(rw, ctxInfo, intervention)
+		return
+	}
+
 	outreq.Close = false
+	if outreq.Body != nil {
+		outreq.Body = &countingReader{outreq.Body, &ctxInfo.bytesReceived}
+	}
 
 	// Remove hop-by-hop headers listed in the "Connection" header.
-	// See RFC 2616, section 14.10.
+	// See RFC 2616, section 14.10. Any upgrade is handled by us.
+	upgradeType := getUpgradeType(outreq.Header)
 	if c := outreq.Header.Get("Connection"); c != "" {
 		for _, f := range strings.Split(c, ",") {
 			if f = strings.TrimSpace(f); f != "" {
@@ -171,13 +200,24 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		outreq.Header.Set("X-Forwarded-For", clientIP)
 	}
 
+	if upgradeType != "" {
+		p.serveUpgrade(rw, outreq, ctxInfo, upgradeType)
+		return
+	}
+
 	res, err := transport.RoundTrip(outreq)
 	if err != nil {
 		p.logf("http: proxy error: %v", err)
+		ctxInfo.responseStatus = http.StatusBadGateway
 		rw.WriteHeader(http.StatusBadGateway)
 		return
 	}
 
+	p.serveResponse(rw, res, ctxInfo)
+}
+
+// serveResponse copies the response of the backend to the client
+func (p *ReverseProxy) serveResponse(rw http.ResponseWriter, res *http.Response, ctxInfo *ContextInfo) {
 	// Remove hop-by-hop headers listed in the
 	// "Connection" header of the response.
 	if c := res.Header.Get("Connection"); c != "" {
@@ -192,13 +232,23 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		res.Header.Del(h)
 	}
 
+	// Determined before the body is filtered, which may drop its length
+	flushInterval := p.FlushInterval
+	if isStreamingResponse(res) {
+		flushInterval = -1
+	}
+
 	if p.ModifyResponse != nil {
 		if err := p.ModifyResponse(res); err != nil {
 			p.logf("http: proxy error: %v", err)
+			ctxInfo.responseStatus = http.StatusBadGateway
 			rw.WriteHeader(http.StatusBadGateway)
 			return
 		}
 	}
+	if p.FilterBody != nil {
+		p.FilterBody(res)
+	}
 
 	copyHeader(rw.Header(), res.Header)
 
@@ -213,16 +263,18 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		rw.Header().Add("Trailer", strings.Join(trailerKeys, ", "))
 	}
 
+	ctxInfo.responseStatus = res.StatusCode
 	rw.WriteHeader(res.StatusCode)
-	if len(res.Trailer) > 0 {
+	if len(res.Trailer) > 0 || flushInterval < 0 {
 		// Force chunking if we saw a response trailer.
 		// This prevents net/http from calculating the length for short
-		// bodies and adding a Content-Length.
+		// bodies and adding a Content-Length. Streaming clients
+		// get to see the header before the first event comes in.
 		if fl, ok := rw.(http.Flusher); ok {
 			fl.Flush()
 		}
 	}
-	p.copyResponse(rw, res.Body)
+	ctxInfo.bytesSent = p.copyResponse(rw, res.Body, flushInterval)
 	res.Body.Close() // close now, instead of defer, to populate res.Trailer
 
 	if len(res.Trailer) == announcedTrailers {
@@ -238,12 +290,38 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
-func (p *ReverseProxy) copyResponse(dst io.Writer, src io.Reader) {
-	if p.FlushInterval != 0 {
+func (p *ReverseProxy) postResponse(req *http.Request, ctxInfo *ContextInfo) {
+	ctxInfo.timeEnd = time.Now()
+	if p.PostResponse != nil {
+		p.PostResponse(req)
+	}
+}
+
+// isStreamingResponse tells if the response is sent bit by bit as events
+// happen, in which case the client should get to see each bit right away.
+func isStreamingResponse(res *http.Response) bool {
+	contentType := strings.ToLower(res.Header.Get("Content-Type"))
+	if strings.HasPrefix(contentType, "text/event-stream") || strings.HasPrefix(contentType, "application/grpc") {
+		return true
+	}
+
+	// Chunked without a length, the backend flushes as it sees fit
+	return res.ContentLength == -1 && res.Request != nil && res.Request.Method != "HEAD" &&
+		res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusNotModified
+}
+
+// copyResponse copies the response body to the client. A negative
+// flushInterval flushes after every write, zero never flushes.
+func (p *ReverseProxy) copyResponse(dst io.Writer, src io.Reader, flushInterval time.Duration) int64 {
+	if flushInterval < 0 {
+		if wf, ok := dst.(writeFlusher); ok {
+			dst = &immediateFlushWriter{wf}
+		}
+	} else if flushInterval != 0 {
 		if wf, ok := dst.(writeFlusher); ok {
 			mlw := &maxLatencyWriter{
 				dst:     wf,
-				latency: p.FlushInterval,
+				latency: flushInterval,
 				done:    make(chan bool),
 			}
 			go mlw.flushLoop()
@@ -256,10 +334,12 @@ func (p *ReverseProxy) copyResponse(dst io.Writer, src io.Reader) {
 	if p.BufferPool != nil {
 		buf = p.BufferPool.Get()
 	}
-	p.copyBuffer(dst, src, buf)
+	written, _ := p.copyBuffer(dst, src, buf)
 	if p.BufferPool != nil {
 		p.BufferPool.Put(buf)
 	}
+
+	return written
 }
 
 func (p *ReverseProxy) copyBuffer(dst io.Writer, src io.Reader, buf []byte) (int64, error) {
@@ -298,11 +378,36 @@ func (p *ReverseProxy) logf(format string, args ...interface{}) {
 	}
 }
 
+// countingReader keeps track of the number of bytes read in n
+type countingReader struct {
+	io.ReadCloser
+	n *int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(r.n, int64(n))
+	return n, err
+}
+
 type writeFlusher interface {
 	io.Writer
 	http.Flusher
 }
 
+// immediateFlushWriter flushes after every write
+type immediateFlushWriter struct {
+	dst writeFlusher
+}
+
+func (w *immediateFlushWriter) Write(p []byte) (int, error) {
+	n, err := w.dst.Write(p)
+	if n > 0 {
+		w.dst.Flush()
+	}
+	return n, err
+}
+
 type maxLatencyWriter struct {
 	dst     writeFlusher
 	latency time.Duration
@@ -2,7 +2,13 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log"
+	"net"
+	"os"
+	"syscall"
+	"time"
 
 	pb "diato/pb"
 	"diato/util/stop"
@@ -11,10 +17,37 @@ import (
 	"google.golang.org/grpc"
 )
 
+// The worker's end of the socket pair set up by the server
+const rpcFd = 6
+
 func (w *Worker) rpcInit() (*grpc.ClientConn, error) {
+	rpcConn, err := w.rpcGetConn()
+	if err != nil {
+		return nil, fmt.Errorf("Could not connect to RPC server: %s", err.Error())
+	}
+
+	connCh := make(chan net.Conn, 1)
+	connCh <- rpcConn
+	dialer := func(string, time.Duration) (net.Conn, error) {
+		select {
+		case conn := <-connCh:
+			return conn, nil
+		default:
+		}
+
+		// There's no way to reestablish the socket pair from within
+		// the chroot. Let the server spawn a new worker instead.
+		log.Print("Lost RPC connection to the server, stopping worker")
+		go stop.Stop()
+		return nil, errors.New("RPC connection to the server was lost")
+	}
+
+	// The socket pair is only accessible to the server and this worker,
+	// so there is nothing to be gained from adding transport security.
 	conn, err := grpc.Dial(
-		"127.0.0.1:2938",
+		"[rpc]",
 		grpc.WithInsecure(),
+		grpc.WithDialer(dialer),
 	)
 	if err != nil {
 		return nil, fmt.Errorf("Could not connect to RPC server: %s", err.Error())
@@ -25,6 +58,38 @@ func (w *Worker) rpcInit() (*grpc.ClientConn, error) {
 	})
 
 	w.userBackend = pb.NewUserBackendClient(conn)
+	w.serverClient = pb.NewServerClient(conn)
+	return conn, nil
+}
+
+// rpcGetConn returns the worker's end of the socket pair set up by the
+// server, after presenting our credentials to the server. The kernel
+// verifies these, so the server knows for sure who it's talking to.
+func (w *Worker) rpcGetConn() (*net.UnixConn, error) {
+	file := os.NewFile(rpcFd, "[rpc]")
+	defer file.Close() // net.FileConn() dup()s the fd
+
+	fileConn, err := net.FileConn(file)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, ok := fileConn.(*net.UnixConn)
+	if !ok {
+		fileConn.Close()
+		return nil, errors.New("RPC socket is not a unix socket")
+	}
+
+	creds := syscall.UnixCredentials(&syscall.Ucred{
+		Pid: int32(os.Getpid()),
+		Uid: uint32(os.Getuid()),
+		Gid: uint32(os.Getgid()),
+	})
+	if _, _, err := conn.WriteMsgUnix([]byte{0}, creds, nil); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Could not present credentials: %s", err.Error())
+	}
+
 	return conn, nil
 }
 
@@ -33,12 +98,33 @@ func (w *Worker) GetGrpcClientConn() *grpc.ClientConn {
 }
 
 func (w *Worker) getConfigContents() ([]byte, error) {
-	// If we use the ServerClient on more than one
-	// place make sure to store it somewhere centrally
-	conf, err := pb.NewServerClient(w.grpcClientConn).GetConfigContents(context.Background(), &empty.Empty{})
+	conf, err := w.serverClient.GetConfigContents(context.Background(), &empty.Empty{})
 	if err != nil {
 		return []byte{}, fmt.Errorf("Could not retrieve config contents: %s", err.Error())
 	}
 
 	return conf.Contents, nil
 }
+
+func (w *Worker) getDisabledModules() (map[string]bool, error) {
+	list, err := w.serverClient.GetDisabledModules(context.Background(), &empty.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("Could not retrieve disabled modules: %s", err.Error())
+	}
+
+	disabled := make(map[string]bool)
+	for _, name := range list.Names {
+		disabled[name] = true
+	}
+
+	return disabled, nil
+}
+
+func (w *Worker) reportModules(names []string) error {
+	_, err := w.serverClient.ReportModules(context.Background(), &pb.ModuleList{Names: names})
+	if err != nil {
+		return fmt.Errorf("Could not report loaded modules: %s", err.Error())
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+// Diato - Reverse Proxying for Hipsters
+//
+// Copyright 2016-2017 Dolf Schimmel
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package worker
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// The time the backend gets to accept a connection and to respond to
+// the upgrade request. From then on UpgradeIdleTimeout applies.
+const upgradeHandshakeTimeout = 30 * time.Second
+
+// getUpgradeType returns 'websocket' if the client asks to switch to it,
+// or an empty string otherwise. Other protocols are not tunneled, as
+// whatever the client sends after switching bypasses the modules. With
+// h2c it could smuggle any number of requests to the backend that way.
+// Such requests are proxied as plain ones, without their Upgrade header.
+func getUpgradeType(h http.Header) string {
+	if !headerHasToken(h, "Connection", "upgrade") || !headerHasToken(h, "Upgrade", "websocket") {
+		return ""
+	}
+
+	return "websocket"
+}
+
+// headerHasToken tells if any of the comma separated values of
+// the header equals the token, ignoring case.
+func headerHasToken(h http.Header, name, token string) bool {
+	for _, v := range h[name] {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// serveUpgrade proxies a request that asks to switch protocols, like a
+// WebSocket handshake. http.Transport can't hand us the connection once
+// the backend agreed, so the request is sent over a connection of our own.
+// After that both connections are no longer HTTP and are copied as is.
+func (p *ReverseProxy) serveUpgrade(rw http.ResponseWriter, req *http.Request, ctxInfo *ContextInfo, upgradeType string) {
+	hijacker, ok := rw.(http.Hijacker)
+	if !ok {
+		p.logf("http: proxy error: can't switch protocols using %T", rw)
+		ctxInfo.responseStatus = http.StatusBadGateway
+		rw.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	backendConn, err := dialer.DialContext(req.Context(), "tcp", req.URL.Host)
+	if err != nil {
+		p.logf("http: proxy error: %v", err)
+		ctxInfo.responseStatus = http.StatusBadGateway
+		rw.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	defer backendConn.Close()
+	backendConn.SetDeadline(time.Now().Add(upgradeHandshakeTimeout))
+
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", upgradeType)
+	if err := req.Write(backendConn); err != nil {
+		p.logf("http: proxy error: %v", err)
+		ctxInfo.responseStatus = http.StatusBadGateway
+		rw.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	backendReader := bufio.NewReader(backendConn)
+	res, err := http.ReadResponse(backendReader, req)
+	if err != nil {
+		p.logf("http: proxy error: %v", err)
+		ctxInfo.responseStatus = http.StatusBadGateway
+		rw.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		// The backend declined, which is just another response
+		p.serveResponse(rw, res, ctxInfo)
+		return
+	}
+
+	if !strings.EqualFold(res.Header.Get("Upgrade"), upgradeType) {
+		p.logf("http: proxy error: backend switched to protocol %q rather than %q",
+			res.Header.Get("Upgrade"), upgradeType)
+		ctxInfo.responseStatus = http.StatusBadGateway
+		rw.WriteHeader(http.StatusBadGateway)
+		return
+	}
+
+	for _, h := range hopHeaders {
+		res.Header.Del(h)
+	}
+	res.Header.Set("Connection", "Upgrade")
+	res.Header.Set("Upgrade", upgradeType)
+
+	if p.ModifyResponse != nil {
+		if err := p.ModifyResponse(res); err != nil {
+			p.logf("http: proxy error: %v", err)
+			ctxInfo.responseStatus = http.StatusBadGateway
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+	}
+
+	clientConn, clientBuf, err := hijacker.Hijack()
+	if err != nil {
+		p.logf("http: proxy error: could not hijack connection: %v", err)
+		return
+	}
+	defer clientConn.Close()
+
+	ctxInfo.responseStatus = res.StatusCode
+	fmt.Fprintf(clientBuf, "HTTP/1.1 %s\r\n", res.Status)
+	res.Header.Write(clientBuf)
+	clientBuf.WriteString("\r\n")
+	if err := clientBuf.Flush(); err != nil {
+		return
+	}
+
+	u := &upgradedConn{
+		client:      clientConn,
+		backend:     backendConn,
+		idleTimeout: p.UpgradeIdleTimeout,
+	}
+	u.extendIdleDeadline()
+
+	// Either side may have sent more than we read so far
+	ctxInfo.bytesSent = u.run(clientBuf.Reader, backendReader, &ctxInfo.bytesReceived)
+}
+
+// upgradedConn copies between the client and the backend once they
+// switched protocols, until either side closes the connection or
+// it was idle for too long.
+type upgradedConn struct {
+	client      net.Conn
+	backend     net.Conn
+	idleTimeout time.Duration
+
+	idleExtended int64 // UnixNano
+}
+
+// run returns the number of bytes sent to the client. The
+// number of bytes received is added to received as it goes.
+func (u *upgradedConn) run(clientReader, backendReader io.Reader, received *int64) int64 {
+	var sent int64
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		sent, _ = io.Copy(u.client, &upgradeReader{backendReader, u, nil})
+		u.client.Close()
+		u.backend.Close()
+		wg.Done()
+	}()
+	go func() {
+		io.Copy(u.backend, &upgradeReader{clientReader, u, received})
+		u.client.Close()
+		u.backend.Close()
+		wg.Done()
+	}()
+	wg.Wait()
+
+	return sent
+}
+
+// extendIdleDeadline moves the deadline of both connections forward.
+// This is done at most once per second, to save on syscalls.
+func (u *upgradedConn) extendIdleDeadline() {
+	now := time.Now()
+	last := atomic.LoadInt64(&u.idleExtended)
+	if now.UnixNano()-last < int64(time.Second) {
+		return
+	}
+	if !atomic.CompareAndSwapInt64(&u.idleExtended, last, now.UnixNano()) {
+		return
+	}
+
+	var deadline time.Time
+	if u.idleTimeout != 0 {
+		deadline = now.Add(u.idleTimeout)
+	}
+
+	u.client.SetDeadline(deadline)
+	u.backend.SetDeadline(deadline)
+}
+
+type upgradeReader struct {
+	r    io.Reader
+	conn *upgradedConn
+	n    *int64
+}
+
+func (r *upgradeReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.conn.extendIdleDeadline()
+		if r.n != nil {
+			atomic.AddInt64(r.n, int64(n))
+		}
+	}
+
+	return n, err
+}
@@ -32,13 +32,18 @@ import (
 
 	"diato/config"
 	"diato/pb"
+	"diato/util/rulesync"
 
 	"google.golang.org/grpc"
 	"gopkg.in/gcfg.v1"
 )
 
 type Worker struct {
-	userBackend diato.UserBackendClient
+	userBackend    diato.UserBackendClient
+	routingHeaders *rulesync.Poller // []string
+	serverClient   diato.ServerClient
+	geoIp          diato.GeoIpClient
+	geoIpCache     *geoIpCache
 
 	modules        *moduleRegistry
 	grpcClientConn *grpc.ClientConn
@@ -58,12 +63,20 @@ func (w *Worker) Start() error {
 	if w.grpcClientConn, err = w.rpcInit(); err != nil {
 		return err
 	}
+	if w.routingHeaders, err = w.routingHeadersInit(); err != nil {
+		return err
+	}
 
 	config, err := w.getConfig()
 	if err != nil {
 		return err
 	}
 
+	if config.GeoIp.Enabled {
+		w.geoIp = diato.NewGeoIpClient(w.grpcClientConn)
+		w.geoIpCache = newGeoIpCache()
+	}
+
 	if err := w.initModules(moduleInitializers, config); err != nil {
 		return err
 	}
@@ -72,13 +85,15 @@ func (w *Worker) Start() error {
 	if err != nil {
 		return err
 	}
-	go w.httpListen(httpListener, false)
+	go w.httpListen(httpListener, false, config)
 
 	httpsListener, err := w.httpGetListener(true)
 	if err != nil {
 		return err
 	}
-	go w.httpListen(httpsListener, true)
+	go w.httpListen(httpsListener, true, config)
+
+	go w.metricsReportLoop()
 
 	return nil
 }